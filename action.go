@@ -15,6 +15,20 @@ type Action struct {
 	OK           string     `json:"ok,omitempty"`
 	Cancel       string     `json:"cancel,omitempty"`
 	Confirmation string     `json:"confirmation,omitempty"`
+	Idempotent   bool       `json:"idempotent,omitempty"`
+}
+
+// Expand returns the target URI of the Action. A templated Action is expanded with the
+// Arguments, completed with the values of its Parameters.
+func (a Action) Expand(args Arguments) (string, error) {
+	if a.Template == "" {
+		return a.Href, nil
+	}
+	args, err := a.Parameters.Arguments(args)
+	if err != nil {
+		return "", err
+	}
+	return ExpandURITemplate(a.Template, args)
 }
 
 // Actions .
@@ -70,6 +84,7 @@ func ActionRelEquals(rel string) func(Action) bool {
 }
 
 const (
+	MethodGET    = "GET"
 	MethodPOST   = "POST"
 	MethodPATCH  = "PATCH"
 	MethodDELETE = "DELETE"
@@ -0,0 +1,117 @@
+package hyper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// WithBasicAuth authenticates every request of the Client with the given credentials.
+func WithBasicAuth(username, password string) ClientOption {
+	return WithRequestSigner(func(r *http.Request) error {
+		r.SetBasicAuth(username, password)
+		return nil
+	})
+}
+
+// WithBearerToken authenticates every request of the Client with a bearer token.
+// If refresh is not nil, it is used to obtain a new token whenever the server responds
+// with 401 Unauthorized, after which the request is sent once more. An empty token is
+// refreshed before the first request.
+func WithBearerToken(token string, refresh func(context.Context) (string, error)) ClientOption {
+	b := &bearerAuth{
+		token:   token,
+		refresh: refresh,
+	}
+	return func(c *Client) {
+		c.auth = append(c.auth, b.middleware)
+	}
+}
+
+// WithRequestSigner calls sign for every request of the Client right before it is sent,
+// including repeated attempts, e.g. to add a signature or custom authentication headers.
+func WithRequestSigner(sign func(*http.Request) error) ClientOption {
+	mw := func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			r = r.Clone(r.Context())
+			if err := sign(r); err != nil {
+				return nil, fmt.Errorf("sign: %v", err)
+			}
+			return next.RoundTrip(r)
+		})
+	}
+	return func(c *Client) {
+		c.auth = append(c.auth, mw)
+	}
+}
+
+type bearerAuth struct {
+	mu      sync.Mutex
+	token   string
+	refresh func(context.Context) (string, error)
+}
+
+func (b *bearerAuth) middleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		token, err := b.current(r.Context())
+		if err != nil {
+			return nil, err
+		}
+		resp, err := next.RoundTrip(b.authorize(r, token))
+		if err != nil || resp.StatusCode != http.StatusUnauthorized || b.refresh == nil {
+			return resp, err
+		}
+		if r.Body != nil && r.Body != http.NoBody && r.GetBody == nil {
+			// the body has been consumed and cannot be sent again
+			return resp, nil
+		}
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		token, err = b.renew(r.Context(), token)
+		if err != nil {
+			return nil, err
+		}
+		retry := b.authorize(r, token)
+		if r.GetBody != nil {
+			retry.Body, err = r.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("refresh: %v", err)
+			}
+		}
+		return next.RoundTrip(retry)
+	})
+}
+
+func (b *bearerAuth) authorize(r *http.Request, token string) *http.Request {
+	r = r.Clone(r.Context())
+	r.Header.Set(HeaderAuthorization, "Bearer "+token)
+	return r
+}
+
+func (b *bearerAuth) current(ctx context.Context) (string, error) {
+	b.mu.Lock()
+	token := b.token
+	b.mu.Unlock()
+	if token != "" || b.refresh == nil {
+		return token, nil
+	}
+	return b.renew(ctx, "")
+}
+
+// renew refreshes the token unless another request already did so since stale was used.
+func (b *bearerAuth) renew(ctx context.Context, stale string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.token != stale {
+		return b.token, nil
+	}
+	token, err := b.refresh(ctx)
+	if err != nil {
+		return "", fmt.Errorf("refresh: %v", err)
+	}
+	b.token = token
+	return token, nil
+}
@@ -0,0 +1,179 @@
+package hyper_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cognicraft/hyper"
+)
+
+func TestBearerTokenRefresh(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(hyper.HeaderAuthorization) != "Bearer fresh" {
+			hyper.WriteError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+			return
+		}
+		c := hyper.ExtractCommand(r)
+		hyper.Write(w, http.StatusOK, hyper.Item{Label: c.Action})
+	}))
+	defer s.Close()
+
+	tests := []struct {
+		name          string
+		token         string
+		expectRefresh int
+	}{
+		{
+			name:          "stale",
+			token:         "stale",
+			expectRefresh: 1,
+		},
+		{
+			name:          "empty",
+			token:         "",
+			expectRefresh: 1,
+		},
+		{
+			name:          "fresh",
+			token:         "fresh",
+			expectRefresh: 0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			refreshed := 0
+			c := hyper.NewClient(hyper.WithBearerToken(test.token, func(ctx context.Context) (string, error) {
+				refreshed++
+				return "fresh", nil
+			}))
+			a := hyper.Action{
+				Href:       s.URL,
+				Parameters: hyper.Parameters{hyper.ActionParameter("rename")},
+			}
+			res, err := c.Submit(context.Background(), a, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// the body must have been replayed after the refresh
+			if want := "rename"; want != res.Label {
+				t.Errorf("want: %s, got: %s", want, res.Label)
+			}
+			if test.expectRefresh != refreshed {
+				t.Errorf("want: %d, got: %d", test.expectRefresh, refreshed)
+			}
+		})
+	}
+}
+
+func TestClientAuth(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hyper.Write(w, http.StatusOK, hyper.Item{
+			Label:       r.Header.Get(hyper.HeaderAuthorization),
+			Description: r.Header.Get("X-Signature"),
+		})
+	}))
+	defer s.Close()
+
+	tests := []struct {
+		name       string
+		opts       []hyper.ClientOption
+		header     http.Header
+		expectItem hyper.Item
+	}{
+		{
+			name:       "basic",
+			opts:       []hyper.ClientOption{hyper.WithBasicAuth("alice", "secret")},
+			expectItem: hyper.Item{Label: "Basic YWxpY2U6c2VjcmV0"},
+		},
+		{
+			name:       "bearer",
+			opts:       []hyper.ClientOption{hyper.WithBearerToken("abc", nil)},
+			expectItem: hyper.Item{Label: "Bearer abc"},
+		},
+		{
+			name: "signer",
+			opts: []hyper.ClientOption{hyper.WithRequestSigner(func(r *http.Request) error {
+				r.Header.Set("X-Signature", r.Method+" "+r.URL.Path)
+				return nil
+			})},
+			expectItem: hyper.Item{Description: "GET /orders"},
+		},
+		{
+			name:       "auth-wins-over-additional-header",
+			opts:       []hyper.ClientOption{hyper.WithBearerToken("abc", nil)},
+			header:     http.Header{hyper.HeaderAuthorization: {"Bearer other"}},
+			expectItem: hyper.Item{Label: "Bearer abc"},
+		},
+		{
+			name:       "additional-header",
+			header:     http.Header{hyper.HeaderAuthorization: {"Bearer other"}},
+			expectItem: hyper.Item{Label: "Bearer other"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := hyper.NewClient(test.opts...)
+			for k, vs := range test.header {
+				c.AdditionalHeader()[k] = vs
+			}
+			res, err := c.Fetch(s.URL + "/orders")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(test.expectItem, res) {
+				t.Errorf("want: %#v, got: %#v", test.expectItem, res)
+			}
+		})
+	}
+}
+
+func TestClientMiddlewareOrder(t *testing.T) {
+	calls := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		hyper.Write(w, http.StatusOK, hyper.Item{})
+	}))
+	defer s.Close()
+
+	var mu sync.Mutex
+	var got []string
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, name)
+	}
+	mw := func(name string) hyper.Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return hyper.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				record(name)
+				return next.RoundTrip(r)
+			})
+		}
+	}
+	c := hyper.NewClient(
+		hyper.WithTransport(mw("transport")(http.DefaultTransport)),
+		hyper.WithRequestSigner(func(r *http.Request) error {
+			record("auth")
+			return nil
+		}),
+		hyper.WithMiddleware(mw("first"), mw("second")),
+		hyper.WithRetry(hyper.RetryPolicy{InitialBackoff: time.Millisecond}),
+	)
+	if _, err := c.Fetch(s.URL); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"first", "second", "auth", "transport", "first", "second", "auth", "transport"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
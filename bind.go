@@ -0,0 +1,383 @@
+package hyper
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bind decodes the Arguments of the Command into dst. See Arguments.Bind.
+func (c Command) Bind(dst interface{}) error {
+	return c.Arguments.Bind(dst)
+}
+
+// Bind decodes the Arguments into dst, which must be a pointer to a struct.
+//
+// Arguments are matched to exported fields by the name in the `hyper:"name"` tag of the field
+// or, without a tag, case-insensitively by the name of the field. Fields tagged with `hyper:"-"`
+// are skipped and fields without a matching argument are left untouched.
+//
+// Nested structs are decoded from nested objects (JSON) or from arguments with dotted names
+// like "address.street" (forms). Slices accept lists as well as single values, pointers are
+// allocated for present arguments only. time.Time is decoded from RFC 3339, "2006-01-02T15:04"
+// and "2006-01-02", time.Duration with time.ParseDuration and other types implementing
+// encoding.TextUnmarshaler with UnmarshalText. Uploaded files are bound to fields of type
+// *File, File or []*File.
+//
+// Empty strings, as sent by forms for blank fields, are treated like absent values: the
+// field is set to its zero value, i.e. pointers are left nil.
+//
+// Arguments that cannot be decoded result in FieldErrors that list every offending field.
+func (a Arguments) Bind(dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind: destination must be a non-nil pointer to a struct, got %T", dst)
+	}
+	var errs FieldErrors
+	bindStruct(v.Elem(), a, "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// FieldError describes why an argument could not be decoded.
+type FieldError struct {
+	Name    string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Name + ": " + e.Message
+}
+
+// FieldErrors is a list of FieldError.
+type FieldErrors []FieldError
+
+func (es FieldErrors) Error() string {
+	var buf bytes.Buffer
+	for i, e := range es {
+		if i > 0 {
+			buf.WriteString("; ")
+		}
+		buf.WriteString(e.Error())
+	}
+	return buf.String()
+}
+
+// Errors converts the FieldErrors to Errors that can be written as part of an Item.
+func (es FieldErrors) Errors() Errors {
+	res := Errors{}
+	for _, e := range es {
+		res = append(res, Error{
+			Label:   e.Name,
+			Message: e.Message,
+		})
+	}
+	return res
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	fileType            = reflect.TypeOf(&File{})
+)
+
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+
+func bindStruct(v reflect.Value, src map[string]interface{}, path string, errs *FieldErrors) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Tag.Get("hyper")
+		if name == "-" {
+			continue
+		}
+		value, ok := lookupArgument(src, name, f.Name)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		bindValue(v.Field(i), value, joinPath(path, name), errs)
+	}
+}
+
+// lookupArgument finds the value for a field. Without a tag, the field name is matched
+// case-insensitively. Arguments with dotted names are collected into a nested object.
+func lookupArgument(src map[string]interface{}, tag string, field string) (interface{}, bool) {
+	name := tag
+	if name == "" {
+		for k := range src {
+			key := k
+			if i := strings.Index(k, "."); i >= 0 {
+				key = k[:i]
+			}
+			if strings.EqualFold(key, field) {
+				name = key
+				break
+			}
+		}
+		if name == "" {
+			return nil, false
+		}
+	}
+	value, ok := src[name]
+	prefix := name + "."
+	var nested map[string]interface{}
+	for k, v := range src {
+		if strings.HasPrefix(k, prefix) {
+			if nested == nil {
+				nested = map[string]interface{}{}
+				if m, isMap := value.(map[string]interface{}); isMap {
+					for mk, mv := range m {
+						nested[mk] = mv
+					}
+				}
+			}
+			nested[k[len(prefix):]] = v
+		}
+	}
+	if nested != nil {
+		return nested, true
+	}
+	return value, ok
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func bindValue(v reflect.Value, value interface{}, path string, errs *FieldErrors) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, FieldError{Name: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if s, ok := value.(string); ok && s == "" {
+		value = nil
+	}
+	if f, ok := value.(*File); ok && v.Type() == fileType.Elem() {
+		v.Set(reflect.ValueOf(*f))
+		return
+	}
+	if v.Kind() == reflect.Ptr {
+		if value == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		bindValue(v.Elem(), value, path, errs)
+		return
+	}
+	if value == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return
+	}
+
+	switch {
+	case v.Type() == fileType.Elem():
+		fail("expected a file, got %T", value)
+		return
+	case v.Type() == timeType:
+		s, ok := value.(string)
+		if !ok {
+			fail("expected a time, got %T", value)
+			return
+		}
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				v.Set(reflect.ValueOf(t))
+				return
+			}
+		}
+		fail("invalid time %q", s)
+		return
+	case v.Type() == durationType:
+		switch value := value.(type) {
+		case string:
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				fail("invalid duration %q", value)
+				return
+			}
+			v.SetInt(int64(d))
+		case float64:
+			v.SetInt(int64(value))
+		default:
+			fail("expected a duration, got %T", value)
+		}
+		return
+	case reflect.PtrTo(v.Type()).Implements(textUnmarshalerType):
+		s, ok := value.(string)
+		if !ok {
+			fail("expected a string, got %T", value)
+			return
+		}
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			fail("%v", err)
+		}
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(value))
+	case reflect.String:
+		switch value := value.(type) {
+		case string:
+			v.SetString(value)
+		case float64, bool:
+			v.SetString(formValue(value))
+		default:
+			fail("expected a string, got %T", value)
+		}
+	case reflect.Bool:
+		switch value := value.(type) {
+		case bool:
+			v.SetBool(value)
+		case string:
+			switch strings.ToLower(value) {
+			case "on":
+				v.SetBool(true)
+			case "off", "":
+				v.SetBool(false)
+			default:
+				b, err := strconv.ParseBool(value)
+				if err != nil {
+					fail("invalid boolean %q", value)
+					return
+				}
+				v.SetBool(b)
+			}
+		default:
+			fail("expected a boolean, got %T", value)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		switch value := value.(type) {
+		case float64:
+			if value != float64(int64(value)) {
+				fail("expected an integer, got %v", value)
+				return
+			}
+			i = int64(value)
+		case string:
+			var err error
+			i, err = strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				fail("invalid integer %q", value)
+				return
+			}
+		default:
+			fail("expected an integer, got %T", value)
+			return
+		}
+		if v.OverflowInt(i) {
+			fail("integer %d out of range", i)
+			return
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		switch value := value.(type) {
+		case float64:
+			if value < 0 || value != float64(uint64(value)) {
+				fail("expected a non-negative integer, got %v", value)
+				return
+			}
+			u = uint64(value)
+		case string:
+			var err error
+			u, err = strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				fail("invalid non-negative integer %q", value)
+				return
+			}
+		default:
+			fail("expected a non-negative integer, got %T", value)
+			return
+		}
+		if v.OverflowUint(u) {
+			fail("integer %d out of range", u)
+			return
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch value := value.(type) {
+		case float64:
+			f = value
+		case string:
+			var err error
+			f, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				fail("invalid number %q", value)
+				return
+			}
+		default:
+			fail("expected a number, got %T", value)
+			return
+		}
+		if v.OverflowFloat(f) {
+			fail("number %v out of range", f)
+			return
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		var values []interface{}
+		switch value := value.(type) {
+		case []interface{}:
+			values = value
+		case []*File:
+			for _, f := range value {
+				values = append(values, f)
+			}
+		case []string:
+			for _, s := range value {
+				values = append(values, s)
+			}
+		default:
+			values = []interface{}{value}
+		}
+		s := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, e := range values {
+			bindValue(s.Index(i), e, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+		v.Set(s)
+	case reflect.Map:
+		m, ok := value.(map[string]interface{})
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			fail("expected an object, got %T", value)
+			return
+		}
+		res := reflect.MakeMapWithSize(v.Type(), len(m))
+		for k, e := range m {
+			ev := reflect.New(v.Type().Elem()).Elem()
+			bindValue(ev, e, joinPath(path, k), errs)
+			res.SetMapIndex(reflect.ValueOf(k).Convert(v.Type().Key()), ev)
+		}
+		v.Set(res)
+	case reflect.Struct:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			fail("expected an object, got %T", value)
+			return
+		}
+		bindStruct(v, m, path, errs)
+	default:
+		fail("unsupported type %s", v.Type())
+	}
+}
@@ -0,0 +1,145 @@
+package hyper_test
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cognicraft/hyper"
+)
+
+type bindAddress struct {
+	Street string `hyper:"street"`
+	Zip    int    `hyper:"zip"`
+}
+
+type bindTarget struct {
+	Name     string        `hyper:"name"`
+	Count    int           `hyper:"count"`
+	Ratio    float64       `hyper:"ratio"`
+	Active   bool          `hyper:"active"`
+	Tags     []string      `hyper:"tags"`
+	Due      time.Time     `hyper:"due"`
+	Timeout  time.Duration `hyper:"timeout"`
+	Limit    *int          `hyper:"limit"`
+	IP       net.IP        `hyper:"ip"`
+	Address  bindAddress   `hyper:"address"`
+	Untagged string
+	Ignored  string `hyper:"-"`
+}
+
+func TestArgumentsBind(t *testing.T) {
+	limit := 10
+	tests := []struct {
+		name         string
+		args         hyper.Arguments
+		expectTarget bindTarget
+		expectErrors hyper.FieldErrors
+	}{
+		{
+			name: "json",
+			args: hyper.Arguments{
+				"name":    "foo",
+				"count":   3.0,
+				"ratio":   0.5,
+				"active":  true,
+				"tags":    []interface{}{"a", "b"},
+				"due":     "2020-01-02T03:04:05Z",
+				"timeout": "1m30s",
+				"limit":   10.0,
+				"ip":      "127.0.0.1",
+				"address": map[string]interface{}{
+					"street": "Main St",
+					"zip":    12345.0,
+				},
+				"untagged": "bar",
+				"Ignored":  "baz",
+			},
+			expectTarget: bindTarget{
+				Name:     "foo",
+				Count:    3,
+				Ratio:    0.5,
+				Active:   true,
+				Tags:     []string{"a", "b"},
+				Due:      time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+				Timeout:  90 * time.Second,
+				Limit:    &limit,
+				IP:       net.ParseIP("127.0.0.1"),
+				Address:  bindAddress{Street: "Main St", Zip: 12345},
+				Untagged: "bar",
+			},
+		},
+		{
+			name: "form",
+			args: hyper.Arguments{
+				"name":           "foo",
+				"count":          "3",
+				"active":         "on",
+				"tags":           "a",
+				"due":            "2020-01-02",
+				"address.street": "Main St",
+				"address.zip":    "12345",
+			},
+			expectTarget: bindTarget{
+				Name:    "foo",
+				Count:   3,
+				Active:  true,
+				Tags:    []string{"a"},
+				Due:     time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+				Address: bindAddress{Street: "Main St", Zip: 12345},
+			},
+		},
+		{
+			name: "blank-form",
+			args: hyper.Arguments{
+				"name":        "",
+				"count":       "",
+				"ratio":       "",
+				"active":      "",
+				"due":         "",
+				"timeout":     "",
+				"limit":       "",
+				"ip":          "",
+				"address.zip": "",
+			},
+			expectTarget: bindTarget{},
+		},
+		{
+			name: "errors",
+			args: hyper.Arguments{
+				"name":        "foo",
+				"count":       "three",
+				"ratio":       "half",
+				"tags":        []interface{}{"a", 1.0},
+				"timeout":     "soon",
+				"address.zip": 1.5,
+			},
+			expectTarget: bindTarget{
+				Name: "foo",
+				Tags: []string{"a", "1"},
+			},
+			expectErrors: hyper.FieldErrors{
+				{Name: "count", Message: `invalid integer "three"`},
+				{Name: "ratio", Message: `invalid number "half"`},
+				{Name: "timeout", Message: `invalid duration "soon"`},
+				{Name: "address.zip", Message: "expected an integer, got 1.5"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := bindTarget{}
+			err := test.args.Bind(&got)
+			if test.expectErrors == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.expectErrors != nil && !reflect.DeepEqual(test.expectErrors, err) {
+				t.Errorf("want: %v, got: %v", test.expectErrors, err)
+			}
+			if !reflect.DeepEqual(test.expectTarget, got) {
+				t.Errorf("want: %#v, got: %#v", test.expectTarget, got)
+			}
+		})
+	}
+}
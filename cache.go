@@ -0,0 +1,448 @@
+package hyper
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheStatus tells how a Response was served by a Client with a Cache.
+type CacheStatus string
+
+const (
+	// CacheMiss is used for responses that have been retrieved from the origin server.
+	CacheMiss CacheStatus = "miss"
+	// CacheHit is used for fresh responses that have been served from the Cache.
+	CacheHit CacheStatus = "hit"
+	// CacheRevalidated is used for stale responses that have been confirmed by the origin server.
+	CacheRevalidated CacheStatus = "revalidated"
+)
+
+// Cache stores responses of a Client. Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, r *CachedResponse)
+	Delete(key string)
+}
+
+// CachedResponse is a response as stored in a Cache.
+type CachedResponse struct {
+	StatusCode   int
+	Header       http.Header
+	Body         []byte
+	RequestTime  time.Time
+	ResponseTime time.Time
+	// Vary holds the values of the request headers that are nominated by the Vary header of the response.
+	Vary http.Header
+	// Variants holds the keys of the responses for the same URL that have been selected by other
+	// values of the Vary headers. It is only used for the response that is stored for the URL itself.
+	Variants []string
+}
+
+// WithCache makes the Client cache responses to GET requests as a private cache according to RFC 7234.
+// Stale responses are revalidated with conditional requests. Successful unsafe requests invalidate
+// the cached responses of their target. Responses with a Vary header are stored per combination of
+// the values of the nominated request headers, e.g. one per Accept-Language; responses with
+// "Vary: *" are not stored.
+func WithCache(cache Cache) ClientOption {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+// NewMemoryCache creates an in-memory Cache that holds up to capacity responses and evicts the
+// least recently used ones. A capacity <= 0 means that the Cache is unbounded.
+func NewMemoryCache(capacity int) *MemoryCache {
+	return &MemoryCache{
+		capacity: capacity,
+		entries:  map[string]*list.Element{},
+		lru:      list.New(),
+	}
+}
+
+// MemoryCache is an in-memory LRU Cache.
+type MemoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	lru      *list.List
+}
+
+type memoryCacheEntry struct {
+	key      string
+	response *CachedResponse
+}
+
+// Get returns the response that is stored for key.
+func (mc *MemoryCache) Get(key string) (*CachedResponse, bool) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	e, ok := mc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	mc.lru.MoveToFront(e)
+	return e.Value.(*memoryCacheEntry).response, true
+}
+
+// Set stores the response for key.
+func (mc *MemoryCache) Set(key string, r *CachedResponse) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if e, ok := mc.entries[key]; ok {
+		e.Value.(*memoryCacheEntry).response = r
+		mc.lru.MoveToFront(e)
+		return
+	}
+	mc.entries[key] = mc.lru.PushFront(&memoryCacheEntry{key: key, response: r})
+	for mc.capacity > 0 && mc.lru.Len() > mc.capacity {
+		last := mc.lru.Back()
+		mc.lru.Remove(last)
+		delete(mc.entries, last.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// Delete removes the response for key.
+func (mc *MemoryCache) Delete(key string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if e, ok := mc.entries[key]; ok {
+		mc.lru.Remove(e)
+		delete(mc.entries, key)
+	}
+}
+
+// Len returns the number of stored responses.
+func (mc *MemoryCache) Len() int {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	return mc.lru.Len()
+}
+
+func (c *Client) doCached(req *http.Request) (*Response, error) {
+	key := req.URL.String()
+	reqCC := parseCacheControl(req.Header)
+	if _, ok := reqCC["no-store"]; ok || isConditional(req.Header) {
+		resp, err := c.roundTripper().RoundTrip(req)
+		if err != nil {
+			return nil, fmt.Errorf("do: %v", err)
+		}
+		defer resp.Body.Close()
+		return decodeResponse(resp)
+	}
+
+	now := time.Now()
+	primary, hasPrimary := c.cache.Get(key)
+	cached, ok := primary, hasPrimary
+	storeKey := key
+	if ok && !cached.matches(req) {
+		storeKey = variantKey(key, req, cached.Vary)
+		cached, ok = c.cache.Get(storeKey)
+		if ok && !cached.matches(req) {
+			ok = false
+		}
+	}
+	if ok {
+		if _, noCache := reqCC["no-cache"]; !noCache && cached.fresh(now, reqCC) {
+			return cached.decode(now, CacheHit)
+		}
+	}
+
+	out := req
+	if ok {
+		out = req.Clone(req.Context())
+		if etag := cached.Header.Get(HeaderETag); etag != "" {
+			out.Header.Set(HeaderIfNoneMatch, etag)
+		}
+		if lm := cached.Header.Get(HeaderLastModified); lm != "" {
+			out.Header.Set(HeaderIfModifiedSince, lm)
+		}
+	}
+	requestTime := time.Now()
+	resp, err := c.roundTripper().RoundTrip(out)
+	if err != nil {
+		return nil, fmt.Errorf("do: %v", err)
+	}
+	defer resp.Body.Close()
+	responseTime := time.Now()
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		updated := *cached
+		updated.Header = cached.Header.Clone()
+		for k, vs := range resp.Header {
+			updated.Header[k] = vs
+		}
+		updated.RequestTime = requestTime
+		updated.ResponseTime = responseTime
+		c.cache.Set(storeKey, &updated)
+		return updated.decode(responseTime, CacheRevalidated)
+	}
+
+	if !isStorable(req, resp) {
+		res, err := decodeResponse(resp)
+		if res != nil {
+			res.Cache = CacheMiss
+		}
+		return res, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read: %v", err)
+	}
+	stored := &CachedResponse{
+		StatusCode:   resp.StatusCode,
+		Header:       resp.Header.Clone(),
+		Body:         body,
+		RequestTime:  requestTime,
+		ResponseTime: responseTime,
+		Vary:         http.Header{},
+	}
+	for _, name := range varyHeaders(resp.Header) {
+		stored.Vary[name] = req.Header[name]
+	}
+	switch {
+	case storeKey == key && hasPrimary:
+		stored.Variants = primary.Variants
+	case storeKey != key && !containsString(primary.Variants, storeKey):
+		updated := *primary
+		updated.Variants = append(append([]string(nil), primary.Variants...), storeKey)
+		c.cache.Set(key, &updated)
+	}
+	c.cache.Set(storeKey, stored)
+	return stored.decode(responseTime, CacheMiss)
+}
+
+// variantKey is the key of the response to req that is selected by the values of the headers
+// that are nominated by vary.
+func variantKey(key string, req *http.Request, vary http.Header) string {
+	names := make([]string, 0, len(vary))
+	for name := range vary {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteString(key)
+	for _, name := range names {
+		b.WriteString("\n")
+		b.WriteString(name)
+		b.WriteString(": ")
+		b.WriteString(strings.Join(req.Header[name], ","))
+	}
+	return b.String()
+}
+
+func containsString(ss []string, s string) bool {
+	for _, e := range ss {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// invalidate removes the cached responses that are affected by a successful unsafe request.
+func (c *Client) invalidate(req *http.Request, resp *http.Response) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return
+	}
+	c.delete(req.URL.String())
+	for _, h := range []string{HeaderLocation, HeaderContentLocation} {
+		if v := resp.Header.Get(h); v != "" {
+			if u, err := url.Parse(v); err == nil {
+				c.delete(req.URL.ResolveReference(u).String())
+			}
+		}
+	}
+}
+
+// delete removes the cached responses for the key, including its variants.
+func (c *Client) delete(key string) {
+	if cr, ok := c.cache.Get(key); ok {
+		for _, v := range cr.Variants {
+			c.cache.Delete(v)
+		}
+	}
+	c.cache.Delete(key)
+}
+
+func (cr *CachedResponse) decode(now time.Time, status CacheStatus) (*Response, error) {
+	header := cr.Header.Clone()
+	header.Set(HeaderAge, strconv.FormatInt(int64(cr.age(now)/time.Second), 10))
+	resp := &http.Response{
+		StatusCode: cr.StatusCode,
+		Header:     header,
+		Body:       ioutil.NopCloser(bytes.NewReader(cr.Body)),
+	}
+	res, err := decodeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	res.Cache = status
+	return res, nil
+}
+
+// matches reports whether the headers nominated by Vary are the same for the request.
+func (cr *CachedResponse) matches(req *http.Request) bool {
+	for name, vs := range cr.Vary {
+		if strings.Join(vs, ",") != strings.Join(req.Header[name], ",") {
+			return false
+		}
+	}
+	return true
+}
+
+// age calculates the current age of the response (RFC 7234, 4.2.3).
+func (cr *CachedResponse) age(now time.Time) time.Duration {
+	apparentAge := time.Duration(0)
+	if date, err := http.ParseTime(cr.Header.Get(HeaderDate)); err == nil && cr.ResponseTime.After(date) {
+		apparentAge = cr.ResponseTime.Sub(date)
+	}
+	ageValue := time.Duration(0)
+	if secs, err := strconv.ParseInt(cr.Header.Get(HeaderAge), 10, 64); err == nil {
+		ageValue = time.Duration(secs) * time.Second
+	}
+	correctedAgeValue := ageValue + cr.ResponseTime.Sub(cr.RequestTime)
+	correctedInitialAge := apparentAge
+	if correctedAgeValue > correctedInitialAge {
+		correctedInitialAge = correctedAgeValue
+	}
+	return correctedInitialAge + now.Sub(cr.ResponseTime)
+}
+
+// lifetime calculates the freshness lifetime of the response (RFC 7234, 4.2.1).
+func (cr *CachedResponse) lifetime() time.Duration {
+	cc := parseCacheControl(cr.Header)
+	if v, ok := cc["max-age"]; ok {
+		if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+		return 0
+	}
+	date, err := http.ParseTime(cr.Header.Get(HeaderDate))
+	if err != nil {
+		date = cr.ResponseTime
+	}
+	if v := cr.Header.Get(HeaderExpires); v != "" {
+		expires, err := http.ParseTime(v)
+		if err != nil {
+			return 0
+		}
+		return expires.Sub(date)
+	}
+	if lm, err := http.ParseTime(cr.Header.Get(HeaderLastModified)); err == nil && date.After(lm) {
+		// heuristic freshness (RFC 7234, 4.2.2)
+		lifetime := date.Sub(lm) / 10
+		if lifetime > 24*time.Hour {
+			lifetime = 24 * time.Hour
+		}
+		return lifetime
+	}
+	return 0
+}
+
+// fresh reports whether the response may be served without revalidation.
+func (cr *CachedResponse) fresh(now time.Time, reqCC map[string]string) bool {
+	cc := parseCacheControl(cr.Header)
+	if _, ok := cc["no-cache"]; ok {
+		return false
+	}
+	lifetime := cr.lifetime()
+	age := cr.age(now)
+	if v, ok := reqCC["max-age"]; ok {
+		if secs, err := strconv.ParseInt(v, 10, 64); err == nil && age > time.Duration(secs)*time.Second {
+			return false
+		}
+	}
+	if v, ok := reqCC["min-fresh"]; ok {
+		if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+			age += time.Duration(secs) * time.Second
+		}
+	}
+	if age < lifetime {
+		return true
+	}
+	if _, ok := cc["must-revalidate"]; ok {
+		return false
+	}
+	if v, ok := reqCC["max-stale"]; ok {
+		if v == "" {
+			return true
+		}
+		if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return age-lifetime < time.Duration(secs)*time.Second
+		}
+	}
+	return false
+}
+
+// isStorable reports whether a response may be stored in a private cache (RFC 7234, 3).
+func isStorable(req *http.Request, resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo:
+	default:
+		return false
+	}
+	if _, ok := parseCacheControl(req.Header)["no-store"]; ok {
+		return false
+	}
+	if _, ok := parseCacheControl(resp.Header)["no-store"]; ok {
+		return false
+	}
+	for _, name := range varyHeaders(resp.Header) {
+		if name == "*" {
+			return false
+		}
+	}
+	return true
+}
+
+func isConditional(h http.Header) bool {
+	for _, name := range []string{HeaderIfMatch, HeaderIfNoneMatch, HeaderIfModifiedSince, HeaderIfUnmodifiedSince, HeaderIfRange} {
+		if h.Get(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func varyHeaders(h http.Header) []string {
+	var names []string
+	for _, v := range h[HeaderVary] {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
+
+// parseCacheControl parses the directives of the Cache-Control header.
+func parseCacheControl(h http.Header) map[string]string {
+	cc := map[string]string{}
+	for _, v := range h[HeaderCacheControl] {
+		for _, d := range strings.Split(v, ",") {
+			d = strings.TrimSpace(d)
+			if d == "" {
+				continue
+			}
+			kv := strings.SplitN(d, "=", 2)
+			name := strings.ToLower(strings.TrimSpace(kv[0]))
+			if len(kv) == 2 {
+				cc[name] = strings.Trim(strings.TrimSpace(kv[1]), `"`)
+			} else {
+				cc[name] = ""
+			}
+		}
+	}
+	return cc
+}
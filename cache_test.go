@@ -0,0 +1,209 @@
+package hyper_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cognicraft/hyper"
+)
+
+func TestClientCache(t *testing.T) {
+	type step struct {
+		method      string
+		header      http.Header
+		expectCache hyper.CacheStatus
+		expectLabel string
+	}
+	tests := []struct {
+		name       string
+		handler    func(w http.ResponseWriter, r *http.Request)
+		steps      []step
+		expectHits int
+	}{
+		{
+			name: "fresh",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(hyper.HeaderCacheControl, "max-age=60")
+				hyper.Write(w, http.StatusOK, hyper.Item{Label: "a"})
+			},
+			steps: []step{
+				{expectCache: hyper.CacheMiss, expectLabel: "a"},
+				{expectCache: hyper.CacheHit, expectLabel: "a"},
+			},
+			expectHits: 1,
+		},
+		{
+			name: "stale-revalidated",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(hyper.HeaderCacheControl, "max-age=0")
+				w.Header().Set(hyper.HeaderETag, `"v1"`)
+				if r.Header.Get(hyper.HeaderIfNoneMatch) == `"v1"` {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				hyper.Write(w, http.StatusOK, hyper.Item{Label: "a"})
+			},
+			steps: []step{
+				{expectCache: hyper.CacheMiss, expectLabel: "a"},
+				{expectCache: hyper.CacheRevalidated, expectLabel: "a"},
+			},
+			expectHits: 2,
+		},
+		{
+			name: "max-stale",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(hyper.HeaderCacheControl, "max-age=0")
+				hyper.Write(w, http.StatusOK, hyper.Item{Label: "a"})
+			},
+			steps: []step{
+				{expectCache: hyper.CacheMiss, expectLabel: "a"},
+				{header: http.Header{hyper.HeaderCacheControl: {"max-stale"}}, expectCache: hyper.CacheHit, expectLabel: "a"},
+			},
+			expectHits: 1,
+		},
+		{
+			name: "min-fresh",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(hyper.HeaderCacheControl, "max-age=60")
+				hyper.Write(w, http.StatusOK, hyper.Item{Label: "a"})
+			},
+			steps: []step{
+				{expectCache: hyper.CacheMiss, expectLabel: "a"},
+				{header: http.Header{hyper.HeaderCacheControl: {"min-fresh=120"}}, expectCache: hyper.CacheMiss, expectLabel: "a"},
+			},
+			expectHits: 2,
+		},
+		{
+			name: "no-store",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(hyper.HeaderCacheControl, "no-store, max-age=60")
+				hyper.Write(w, http.StatusOK, hyper.Item{Label: "a"})
+			},
+			steps: []step{
+				{expectCache: hyper.CacheMiss, expectLabel: "a"},
+				{expectCache: hyper.CacheMiss, expectLabel: "a"},
+			},
+			expectHits: 2,
+		},
+		{
+			name: "vary",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(hyper.HeaderCacheControl, "max-age=60")
+				w.Header().Set(hyper.HeaderVary, hyper.HeaderAcceptLanguage)
+				hyper.Write(w, http.StatusOK, hyper.Item{Label: r.Header.Get(hyper.HeaderAcceptLanguage)})
+			},
+			steps: []step{
+				{header: http.Header{hyper.HeaderAcceptLanguage: {"en"}}, expectCache: hyper.CacheMiss, expectLabel: "en"},
+				{header: http.Header{hyper.HeaderAcceptLanguage: {"de"}}, expectCache: hyper.CacheMiss, expectLabel: "de"},
+				{header: http.Header{hyper.HeaderAcceptLanguage: {"en"}}, expectCache: hyper.CacheHit, expectLabel: "en"},
+				{header: http.Header{hyper.HeaderAcceptLanguage: {"de"}}, expectCache: hyper.CacheHit, expectLabel: "de"},
+			},
+			expectHits: 2,
+		},
+		{
+			name: "vary-invalidation",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(hyper.HeaderCacheControl, "max-age=60")
+				w.Header().Set(hyper.HeaderVary, hyper.HeaderAcceptLanguage)
+				hyper.Write(w, http.StatusOK, hyper.Item{Label: r.Header.Get(hyper.HeaderAcceptLanguage)})
+			},
+			steps: []step{
+				{header: http.Header{hyper.HeaderAcceptLanguage: {"en"}}, expectCache: hyper.CacheMiss, expectLabel: "en"},
+				{header: http.Header{hyper.HeaderAcceptLanguage: {"de"}}, expectCache: hyper.CacheMiss, expectLabel: "de"},
+				{method: hyper.MethodPOST, header: http.Header{hyper.HeaderAcceptLanguage: {"en"}}, expectLabel: "en"},
+				{header: http.Header{hyper.HeaderAcceptLanguage: {"de"}}, expectCache: hyper.CacheMiss, expectLabel: "de"},
+				{header: http.Header{hyper.HeaderAcceptLanguage: {"en"}}, expectCache: hyper.CacheMiss, expectLabel: "en"},
+				{header: http.Header{hyper.HeaderAcceptLanguage: {"de"}}, expectCache: hyper.CacheHit, expectLabel: "de"},
+			},
+			expectHits: 5,
+		},
+		{
+			name: "invalidation",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(hyper.HeaderCacheControl, "max-age=60")
+				hyper.Write(w, http.StatusOK, hyper.Item{Label: r.Method})
+			},
+			steps: []step{
+				{expectCache: hyper.CacheMiss, expectLabel: hyper.MethodGET},
+				{method: hyper.MethodPOST, expectLabel: hyper.MethodPOST},
+				{expectCache: hyper.CacheMiss, expectLabel: hyper.MethodGET},
+				{expectCache: hyper.CacheHit, expectLabel: hyper.MethodGET},
+			},
+			expectHits: 3,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hits := 0
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits++
+				test.handler(w, r)
+			}))
+			defer s.Close()
+
+			c := hyper.NewClient(hyper.WithCache(hyper.NewMemoryCache(10)))
+			for i, step := range test.steps {
+				method := step.method
+				if method == "" {
+					method = hyper.MethodGET
+				}
+				req, err := http.NewRequest(method, s.URL+"/orders", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for k, vs := range step.header {
+					req.Header[k] = vs
+				}
+				res, err := c.Do(req)
+				if err != nil {
+					t.Fatalf("step %d: unexpected error: %v", i, err)
+				}
+				if step.expectCache != res.Cache {
+					t.Errorf("step %d: want: %q, got: %q", i, step.expectCache, res.Cache)
+				}
+				if step.expectLabel != res.Item.Label {
+					t.Errorf("step %d: want: %s, got: %s", i, step.expectLabel, res.Item.Label)
+				}
+			}
+			if test.expectHits != hits {
+				t.Errorf("want: %d hits, got: %d", test.expectHits, hits)
+			}
+		})
+	}
+}
+
+func TestMemoryCacheUnbounded(t *testing.T) {
+	mc := hyper.NewMemoryCache(0)
+	for k := 0; k < 100; k++ {
+		mc.Set(fmt.Sprint(k), &hyper.CachedResponse{StatusCode: http.StatusOK})
+	}
+	if want, got := 100, mc.Len(); want != got {
+		t.Errorf("want: %d, got: %d", want, got)
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	mc := hyper.NewMemoryCache(2)
+	mc.Set("a", &hyper.CachedResponse{StatusCode: http.StatusOK})
+	mc.Set("b", &hyper.CachedResponse{StatusCode: http.StatusOK})
+	// a becomes the most recently used one, so b is evicted
+	if _, ok := mc.Get("a"); !ok {
+		t.Fatalf("want: a, got: nothing")
+	}
+	mc.Set("c", &hyper.CachedResponse{StatusCode: http.StatusOK})
+
+	if want, got := 2, mc.Len(); want != got {
+		t.Errorf("want: %d, got: %d", want, got)
+	}
+	for key, expect := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := mc.Get(key); expect != ok {
+			t.Errorf("%s: want: %t, got: %t", key, expect, ok)
+		}
+	}
+	mc.Delete("a")
+	if _, ok := mc.Get("a"); ok {
+		t.Errorf("want: a deleted, got: a")
+	}
+}
@@ -1,21 +1,35 @@
 package hyper
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 )
 
-func NewClient() *Client {
-	return &Client{
+func NewClient(opts ...ClientOption) *Client {
+	c := &Client{
 		httpClient:       &http.Client{},
 		additionalHeader: http.Header{},
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 type Client struct {
 	httpClient       *http.Client
 	additionalHeader http.Header
+	middleware       []Middleware
+	auth             []Middleware
+	cache            Cache
+	retry            *RetryPolicy
+	baseURL          *url.URL
 }
 
 func (c *Client) AdditionalHeader() http.Header {
@@ -23,26 +37,252 @@ func (c *Client) AdditionalHeader() http.Header {
 }
 
 func (c *Client) Fetch(url string) (Item, error) {
+	return c.FetchContext(context.Background(), url)
+}
+
+// FetchContext retrieves the Item at the given url. Responses with a status code
+// of 400 or above result in a *ResponseError.
+func (c *Client) FetchContext(ctx context.Context, url string) (Item, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return Item{}, fmt.Errorf("create: %v", err)
 	}
-	req.Header.Set(HeaderContentType, ContentTypeHyperItem)
+	return c.do(req.WithContext(ctx))
+}
+
+// Submit executes the Action with the given Arguments and returns the resulting Item.
+// Hidden parameters of the Action (e.g. the one created by ActionParameter) are added
+// to the arguments unless they are explicitly supplied. The body is encoded according
+// to Action.Encoding, so that it can be read on the server side with ExtractCommand.
+// With ContentTypeMultipartForm, arguments of type *File or []*File are uploaded as files.
+// Multipart bodies are streamed and cannot be replayed, so such submissions are neither retried
+// (see WithRetry) nor repeated after the bearer token has been refreshed (see WithBearerToken).
+// Submissions of an Idempotent Action may be retried (see WithRetry).
+func (c *Client) Submit(ctx context.Context, a Action, args Arguments) (Item, error) {
+	method := a.Method
+	if method == "" {
+		method = MethodPOST
+	}
+	if a.Idempotent {
+		ctx = withIdempotent(ctx)
+	}
+	href, err := a.Expand(args)
+	if err != nil {
+		return Item{}, fmt.Errorf("expand: %v", err)
+	}
+	args = actionArguments(a, args)
+	if method == MethodGET {
+		u, err := url.Parse(href)
+		if err != nil {
+			return Item{}, fmt.Errorf("create: %v", err)
+		}
+		q := u.Query()
+		for k, vs := range formValues(args) {
+			q[k] = vs
+		}
+		u.RawQuery = q.Encode()
+		req, err := http.NewRequest(method, u.String(), nil)
+		if err != nil {
+			return Item{}, fmt.Errorf("create: %v", err)
+		}
+		return c.do(req.WithContext(ctx))
+	}
+	body, ct, err := encodeArguments(a.Encoding, args)
+	if err != nil {
+		return Item{}, fmt.Errorf("encode: %v", err)
+	}
+	req, err := http.NewRequest(method, href, body)
+	if err != nil {
+		return Item{}, fmt.Errorf("create: %v", err)
+	}
+	req.Header.Set(HeaderContentType, ct)
+	return c.do(req.WithContext(ctx))
+}
+
+// Follow navigates the Link of the Item with the given rel and returns the target Item.
+// Templated links are expanded with the given Arguments, completed with the values
+// declared by the Parameters of the link. The Accept and AcceptLanguage of the link are
+// sent along with the request.
+func (c *Client) Follow(ctx context.Context, i Item, rel string, args Arguments) (Item, error) {
+	l, ok := i.Links.FindByRel(rel)
+	if !ok {
+		return Item{}, fmt.Errorf("follow: no link with rel %q", rel)
+	}
+	href, err := l.Expand(args)
+	if err != nil {
+		return Item{}, fmt.Errorf("follow %s: %v", rel, err)
+	}
+	req, err := http.NewRequest(MethodGET, href, nil)
+	if err != nil {
+		return Item{}, fmt.Errorf("create: %v", err)
+	}
+	if l.Accept != "" {
+		req.Header.Set(HeaderAccept, l.Accept)
+	}
+	if l.AcceptLanguage != "" {
+		req.Header.Set(HeaderAcceptLanguage, l.AcceptLanguage)
+	}
+	return c.do(req.WithContext(ctx))
+}
+
+// Response is a decoded response to a hyper request.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Item       Item
+	// Cache tells how the response was served if the Client has a Cache.
+	Cache CacheStatus
+}
+
+// Do sends the request and decodes the Item of the response. Responses with a status
+// code of 400 or above result in a *ResponseError. Headers that are set on the request
+// take precedence over the AdditionalHeader of the Client. Unless specified otherwise,
+// AcceptHyperItem is sent as the Accept header. Relative URLs are resolved against the
+// base URL of the Client (see WithBaseURL). Successful responses must either be
+// hyper-items or plain JSON.
+func (c *Client) Do(req *http.Request) (*Response, error) {
+	if c.baseURL != nil && !req.URL.IsAbs() {
+		req.URL = c.baseURL.ResolveReference(req.URL)
+		req.Host = req.URL.Host
+	}
 	for k, v := range c.additionalHeader {
-		if k == HeaderContentType {
+		if _, ok := req.Header[k]; ok {
 			continue
 		}
 		req.Header[k] = v
 	}
-	resp, err := c.httpClient.Do(req)
+	if req.Header.Get(HeaderAccept) == "" {
+		req.Header.Set(HeaderAccept, AcceptHyperItem)
+	}
+	if c.cache != nil && req.Method == MethodGET {
+		return c.doCached(req)
+	}
+	resp, err := c.roundTripper().RoundTrip(req)
 	if err != nil {
-		return Item{}, fmt.Errorf("do: %v", err)
+		return nil, fmt.Errorf("do: %v", err)
 	}
 	defer resp.Body.Close()
-	res := Item{}
-	err = json.NewDecoder(resp.Body).Decode(&res)
-	if err != nil {
-		return Item{}, fmt.Errorf("decode: %v", err)
+	if c.cache != nil && resp.StatusCode < 400 {
+		c.invalidate(req, resp)
+	}
+	return decodeResponse(resp)
+}
+
+func decodeResponse(resp *http.Response) (*Response, error) {
+	if resp.StatusCode >= 400 {
+		return nil, newResponseError(resp)
+	}
+	res := &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+	}
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+		return res, nil
+	}
+	if v := resp.Header.Get(HeaderContentType); v != "" {
+		ct, _ := ExtractContentType(resp.Header)
+		if !ct.IsJSON() {
+			return nil, fmt.Errorf("unexpected content type: %s", v)
+		}
+	}
+	err := json.NewDecoder(resp.Body).Decode(&res.Item)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("decode: %v", err)
 	}
 	return res, nil
 }
+
+// roundTripper returns the chain of retries, middleware and authentication around the http.Client.
+func (c *Client) roundTripper() http.RoundTripper {
+	var rt http.RoundTripper = RoundTripperFunc(c.httpClient.Do)
+	for i := len(c.auth) - 1; i >= 0; i-- {
+		rt = c.auth[i](rt)
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		rt = c.middleware[i](rt)
+	}
+	if c.retry != nil {
+		rt = c.retry.middleware(rt)
+	}
+	return rt
+}
+
+func (c *Client) do(req *http.Request) (Item, error) {
+	res, err := c.Do(req)
+	if err != nil {
+		return Item{}, err
+	}
+	return res.Item, nil
+}
+
+// actionArguments returns a copy of args that is completed with the values of the hidden parameters of a.
+func actionArguments(a Action, args Arguments) Arguments {
+	res := Arguments{}
+	for _, p := range a.Parameters {
+		if p.Type == TypeHidden && p.Value != nil {
+			res[p.Name] = p.Value
+		}
+	}
+	for k, v := range args {
+		res[k] = v
+	}
+	return res
+}
+
+// encodeArguments encodes args as the body of a request with the given encoding.
+// An empty encoding defaults to JSON.
+func encodeArguments(encoding string, args Arguments) (io.Reader, string, error) {
+	ct := ContentType{}
+	ct.Parse(encoding)
+	switch ct.MediaType() {
+	case "":
+		encoding = ContentTypeJSON
+		fallthrough
+	case ContentTypeJSON, ContentTypeHyperItem:
+		bs, err := json.Marshal(args)
+		if err != nil {
+			return nil, "", err
+		}
+		return bytes.NewReader(bs), encoding, nil
+	case ContentTypeURLEncoded:
+		return bytes.NewReader([]byte(formValues(args).Encode())), encoding, nil
+	case ContentTypeMultipartForm:
+		body, ct := encodeMultipart(args)
+		return body, ct, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported encoding: %s", encoding)
+	}
+}
+
+// formValues converts args to url.Values. Slices result in multiple values for the same name.
+func formValues(args Arguments) url.Values {
+	values := url.Values{}
+	for k, v := range args {
+		switch v := v.(type) {
+		case nil:
+			continue
+		case []string:
+			values[k] = append(values[k], v...)
+		case []interface{}:
+			for _, e := range v {
+				values.Add(k, formValue(e))
+			}
+		default:
+			values.Add(k, formValue(v))
+		}
+	}
+	return values
+}
+
+func formValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,484 @@
+package hyper_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/cognicraft/hyper"
+)
+
+func TestClientSubmit(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := hyper.ExtractCommand(r)
+		res := hyper.Item{
+			Label: r.Method,
+			Rel:   c.Action,
+		}
+		for n, v := range c.Arguments {
+			res.AddProperty(hyper.Property{Name: n, Value: v})
+		}
+		hyper.Write(w, http.StatusOK, res)
+	}))
+	defer s.Close()
+
+	tests := []struct {
+		name        string
+		action      hyper.Action
+		args        hyper.Arguments
+		expectLabel string
+		expectRel   string
+		expectArgs  map[string]interface{}
+	}{
+		{
+			name: "json",
+			action: hyper.Action{
+				Href: s.URL,
+				Parameters: hyper.Parameters{
+					hyper.ActionParameter("rename"),
+					{Name: "name", Type: hyper.TypeText},
+				},
+			},
+			args:        hyper.Arguments{"name": "foo", "count": 3.0},
+			expectLabel: hyper.MethodPOST,
+			expectRel:   "rename",
+			expectArgs:  map[string]interface{}{"name": "foo", "count": 3.0},
+		},
+		{
+			name: "url-encoded",
+			action: hyper.Action{
+				Href:     s.URL,
+				Method:   hyper.MethodPATCH,
+				Encoding: hyper.ContentTypeURLEncoded,
+				Parameters: hyper.Parameters{
+					hyper.ActionParameter("tag"),
+				},
+			},
+			args:        hyper.Arguments{"tag": []string{"a", "b"}, "count": 3.0},
+			expectLabel: hyper.MethodPATCH,
+			expectRel:   "tag",
+			expectArgs:  map[string]interface{}{"tag": []string{"a", "b"}, "count": "3"},
+		},
+		{
+			name: "explicit-action",
+			action: hyper.Action{
+				Href:   s.URL,
+				Method: hyper.MethodDELETE,
+				Parameters: hyper.Parameters{
+					hyper.ActionParameter("delete"),
+				},
+			},
+			args:        hyper.Arguments{hyper.NameAction: "archive"},
+			expectLabel: hyper.MethodDELETE,
+			expectRel:   "archive",
+			expectArgs:  map[string]interface{}{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := hyper.NewClient()
+			res, err := c.Submit(context.Background(), test.action, test.args)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.expectLabel != res.Label {
+				t.Errorf("want: %s, got: %s", test.expectLabel, res.Label)
+			}
+			if test.expectRel != res.Rel {
+				t.Errorf("want: %s, got: %s", test.expectRel, res.Rel)
+			}
+			got := map[string]interface{}{}
+			for _, p := range res.Properties {
+				got[p.Name] = p.Value
+				if vs, ok := p.Value.([]interface{}); ok {
+					ss := []string{}
+					for _, v := range vs {
+						ss = append(ss, v.(string))
+					}
+					got[p.Name] = ss
+				}
+			}
+			if !reflect.DeepEqual(test.expectArgs, got) {
+				t.Errorf("want: %#v, got: %#v", test.expectArgs, got)
+			}
+		})
+	}
+}
+
+func TestClientFollow(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hyper.Write(w, http.StatusOK, hyper.Item{
+			Label:       r.URL.RequestURI(),
+			Description: r.Header.Get(hyper.HeaderAcceptLanguage),
+		})
+	}))
+	defer s.Close()
+
+	item := hyper.Item{
+		Links: hyper.Links{
+			{
+				Rel:            "search",
+				Template:       s.URL + "/search{?q,page}",
+				AcceptLanguage: "de",
+				Parameters: hyper.Parameters{
+					{Name: "q", Type: hyper.TypeText, Required: true},
+					{Name: "page", Type: hyper.TypeHidden, Value: 1},
+				},
+			},
+		},
+	}
+
+	c := hyper.NewClient()
+	res, err := c.Follow(context.Background(), item, "search", hyper.Arguments{"q": "foo bar"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "/search?q=foo%20bar&page=1"; want != res.Label {
+		t.Errorf("want: %s, got: %s", want, res.Label)
+	}
+	if want := "de"; want != res.Description {
+		t.Errorf("want: %s, got: %s", want, res.Description)
+	}
+	if _, err := c.Follow(context.Background(), item, "search", nil); err == nil {
+		t.Errorf("want: error for missing required parameter, got: nil")
+	}
+	if _, err := c.Follow(context.Background(), item, "next", nil); err == nil {
+		t.Errorf("want: error for missing rel, got: nil")
+	}
+}
+
+func TestClientFetchError(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/hyper":
+			hyper.WriteError(w, http.StatusInternalServerError, fmt.Errorf("boom"))
+		case "/json":
+			w.Header().Set(hyper.HeaderContentType, hyper.ContentTypeJSON)
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprint(w, `{"message":"upstream unavailable"}`)
+		case "/text":
+			http.Error(w, "no such thing", http.StatusNotFound)
+		case "/text-upper":
+			w.Header().Set(hyper.HeaderContentType, "Text/Plain; Charset=UTF-8")
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprint(w, "already exists")
+		default:
+			w.Header().Set(hyper.HeaderContentType, "text/html")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "<html><body>Not Found</body></html>")
+		}
+	}))
+	defer s.Close()
+
+	tests := []struct {
+		path         string
+		expectStatus int
+		expectErrors hyper.Errors
+	}{
+		{
+			path:         "/hyper",
+			expectStatus: http.StatusInternalServerError,
+			expectErrors: hyper.Errors{{Message: "boom"}},
+		},
+		{
+			path:         "/json",
+			expectStatus: http.StatusBadGateway,
+			expectErrors: hyper.Errors{{Message: "upstream unavailable"}},
+		},
+		{
+			path:         "/text",
+			expectStatus: http.StatusNotFound,
+			expectErrors: hyper.Errors{{Message: "no such thing"}},
+		},
+		{
+			path:         "/text-upper",
+			expectStatus: http.StatusConflict,
+			expectErrors: hyper.Errors{{Message: "already exists"}},
+		},
+		{
+			path:         "/html",
+			expectStatus: http.StatusNotFound,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			c := hyper.NewClient()
+			_, err := c.FetchContext(context.Background(), s.URL+test.path)
+			rerr, ok := err.(*hyper.ResponseError)
+			if !ok {
+				t.Fatalf("want: *hyper.ResponseError, got: %#v", err)
+			}
+			if test.expectStatus != rerr.StatusCode {
+				t.Errorf("want: %d, got: %d", test.expectStatus, rerr.StatusCode)
+			}
+			if !reflect.DeepEqual(test.expectErrors, rerr.Errors) {
+				t.Errorf("want: %#v, got: %#v", test.expectErrors, rerr.Errors)
+			}
+		})
+	}
+}
+
+func TestClientBaseURL(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hyper.Write(w, http.StatusOK, hyper.Item{
+			Label: r.Method + " " + r.URL.RequestURI(),
+			Links: hyper.Links{{Rel: "next", Href: "page/2"}},
+		})
+	}))
+	defer s.Close()
+
+	base, err := url.Parse(s.URL + "/api/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := hyper.NewClient(hyper.WithBaseURL(base))
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		do          func() (hyper.Item, error)
+		expectLabel string
+	}{
+		{
+			name:        "absolute-path",
+			do:          func() (hyper.Item, error) { return c.FetchContext(ctx, "/orders") },
+			expectLabel: "GET /orders",
+		},
+		{
+			name:        "relative",
+			do:          func() (hyper.Item, error) { return c.FetchContext(ctx, "orders?page=1") },
+			expectLabel: "GET /api/orders?page=1",
+		},
+		{
+			name:        "absolute-url",
+			do:          func() (hyper.Item, error) { return c.FetchContext(ctx, s.URL+"/other") },
+			expectLabel: "GET /other",
+		},
+		{
+			name: "follow",
+			do: func() (hyper.Item, error) {
+				return c.Follow(ctx, hyper.Item{Links: hyper.Links{{Rel: "next", Href: "page/2"}}}, "next", nil)
+			},
+			expectLabel: "GET /api/page/2",
+		},
+		{
+			name:        "submit",
+			do:          func() (hyper.Item, error) { return c.Submit(ctx, hyper.Action{Href: "orders"}, nil) },
+			expectLabel: "POST /api/orders",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			i, err := test.do()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.expectLabel != i.Label {
+				t.Errorf("want: %s, got: %s", test.expectLabel, i.Label)
+			}
+		})
+	}
+}
+
+func TestClientDo(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/headers":
+			hyper.Write(w, http.StatusOK, hyper.Item{
+				Label:       r.Header.Get(hyper.HeaderAccept),
+				Description: r.Header.Get(hyper.HeaderAcceptLanguage),
+			})
+		case "/html":
+			w.Header().Set(hyper.HeaderContentType, "text/html")
+			fmt.Fprint(w, "<html></html>")
+		case "/no-content":
+			w.WriteHeader(http.StatusNoContent)
+		case "/not-modified":
+			w.Header().Set(hyper.HeaderContentType, "text/html")
+			w.WriteHeader(http.StatusNotModified)
+		}
+	}))
+	defer s.Close()
+
+	c := hyper.NewClient()
+	c.AdditionalHeader().Set(hyper.HeaderAcceptLanguage, "en")
+
+	tests := []struct {
+		name         string
+		path         string
+		header       http.Header
+		expectStatus int
+		expectItem   hyper.Item
+		expectErr    bool
+	}{
+		{
+			name:         "default-accept",
+			path:         "/headers",
+			expectStatus: http.StatusOK,
+			expectItem:   hyper.Item{Label: hyper.AcceptHyperItem, Description: "en"},
+		},
+		{
+			name: "request-header-wins",
+			path: "/headers",
+			header: http.Header{
+				hyper.HeaderAccept:         {hyper.ContentTypeJSON},
+				hyper.HeaderAcceptLanguage: {"de"},
+			},
+			expectStatus: http.StatusOK,
+			expectItem:   hyper.Item{Label: hyper.ContentTypeJSON, Description: "de"},
+		},
+		{
+			name:      "non-json",
+			path:      "/html",
+			expectErr: true,
+		},
+		{
+			name:         "no-content",
+			path:         "/no-content",
+			expectStatus: http.StatusNoContent,
+		},
+		{
+			name:         "not-modified",
+			path:         "/not-modified",
+			expectStatus: http.StatusNotModified,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(hyper.MethodGET, s.URL+test.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, vs := range test.header {
+				req.Header[k] = vs
+			}
+			res, err := c.Do(req)
+			if test.expectErr {
+				if err == nil {
+					t.Fatalf("want: error, got: %#v", res)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.expectStatus != res.StatusCode {
+				t.Errorf("want: %d, got: %d", test.expectStatus, res.StatusCode)
+			}
+			if !reflect.DeepEqual(test.expectItem, res.Item) {
+				t.Errorf("want: %#v, got: %#v", test.expectItem, res.Item)
+			}
+		})
+	}
+}
+
+type uploadTarget struct {
+	Title      string        `hyper:"title"`
+	Attachment *hyper.File   `hyper:"attachment"`
+	Pages      []*hyper.File `hyper:"pages"`
+}
+
+func TestClientSubmitMultipart(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := hyper.ReadCommand(r)
+		if err != nil {
+			hyper.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		var u uploadTarget
+		if err := c.Bind(&u); err != nil {
+			hyper.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		res := hyper.Item{Label: u.Title, Rel: c.Action}
+		for _, f := range append([]*hyper.File{u.Attachment}, u.Pages...) {
+			if f == nil {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				hyper.WriteError(w, http.StatusInternalServerError, err)
+				return
+			}
+			bs, _ := ioutil.ReadAll(rc)
+			rc.Close()
+			res.AddProperty(hyper.Property{
+				Name:    f.Filename,
+				Value:   f.ContentType,
+				Display: fmt.Sprintf("%d:%t", f.Size, bytes.Equal(bs, content(f.Filename, len(bs)))),
+			})
+		}
+		hyper.Write(w, http.StatusOK, res)
+	}))
+	defer s.Close()
+
+	opened := 0
+	file := func(name string, size int) *hyper.File {
+		return hyper.NewFile(name, "text/plain", func() (io.ReadCloser, error) {
+			opened++
+			return ioutil.NopCloser(bytes.NewReader(content(name, size))), nil
+		})
+	}
+	// the attachment exceeds the memory threshold and is stored in a temporary file
+	size := int(hyper.DefaultMultipartMemory) + 1024
+	action := hyper.Action{
+		Href:       s.URL,
+		Encoding:   hyper.ContentTypeMultipartForm,
+		Parameters: hyper.Parameters{hyper.ActionParameter("upload")},
+	}
+	args := hyper.Arguments{
+		"title":      "report",
+		"attachment": file("a.txt", size),
+		"pages":      []*hyper.File{file("p1.txt", 10), file("p2.txt", 20)},
+	}
+
+	res, err := hyper.NewClient().Submit(context.Background(), action, args)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "report"; want != res.Label {
+		t.Errorf("want: %s, got: %s", want, res.Label)
+	}
+	if want := "upload"; want != res.Rel {
+		t.Errorf("want: %s, got: %s", want, res.Rel)
+	}
+	want := map[string]string{
+		"a.txt":  fmt.Sprintf("%d:true", size),
+		"p1.txt": "10:true",
+		"p2.txt": "20:true",
+	}
+	got := map[string]string{}
+	for _, p := range res.Properties {
+		got[p.Name] = p.Display
+		if p.Value != "text/plain" {
+			t.Errorf("%s: want: text/plain, got: %v", p.Name, p.Value)
+		}
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	// files must not be opened if the request is never sent
+	opened = 0
+	c := hyper.NewClient(hyper.WithRequestSigner(func(r *http.Request) error {
+		return fmt.Errorf("no key")
+	}))
+	if _, err := c.Submit(context.Background(), action, hyper.Arguments{"attachment": file("a.txt", 10)}); err == nil {
+		t.Errorf("want: error, got: nil")
+	}
+	if opened != 0 {
+		t.Errorf("want: 0 files opened, got: %d", opened)
+	}
+}
+
+// content returns size bytes of content that depend on the name.
+func content(name string, size int) []byte {
+	return bytes.Repeat([]byte(name[:1]), size)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cognicraft/hyper"
+)
+
+// parseArguments parses arguments of the form key=value. Values are converted according
+// to the parameter with the same name, see argumentValue.
+func parseArguments(ps hyper.Parameters, kvs []string) (hyper.Arguments, error) {
+	args := hyper.Arguments{}
+	for _, kv := range kvs {
+		i := strings.Index(kv, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid argument %q, expected key=value", kv)
+		}
+		k, raw := kv[:i], kv[i+1:]
+		p, _ := ps.FindByName(k)
+		v, err := argumentValue(p, raw)
+		if err != nil {
+			return nil, err
+		}
+		if prev, ok := args[k]; ok {
+			// repeated keys are collected into a list
+			if l, isList := prev.([]interface{}); isList {
+				args[k] = append(l, v)
+			} else {
+				args[k] = []interface{}{prev, v}
+			}
+			continue
+		}
+		args[k] = v
+	}
+	return args, nil
+}
+
+// argumentValue converts the raw value for a parameter: numbers are parsed, values of
+// parameters that accept multiple values are split at commas and files are given as @path.
+func argumentValue(p hyper.Parameter, raw string) (interface{}, error) {
+	if p.Multiple && strings.Contains(raw, ",") {
+		var vs []interface{}
+		for _, s := range strings.Split(raw, ",") {
+			v, err := argumentValue(hyper.Parameter{Name: p.Name, Type: p.Type}, strings.TrimSpace(s))
+			if err != nil {
+				return nil, err
+			}
+			vs = append(vs, v)
+		}
+		return vs, nil
+	}
+	switch p.Type {
+	case hyper.TypeNumber, hyper.TypeRange:
+		if f, err := strconv.ParseFloat(raw, 64); err == nil {
+			return f, nil
+		}
+	case hyper.TypeFile:
+		if !strings.HasPrefix(raw, "@") {
+			return nil, fmt.Errorf("%s: expected @path of a file", p.Name)
+		}
+		path := raw[1:]
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("%s: %v", p.Name, err)
+		}
+		return hyper.NewFile(filepath.Base(path), mime.TypeByExtension(filepath.Ext(path)), func() (io.ReadCloser, error) {
+			return os.Open(path)
+		}), nil
+	}
+	return raw, nil
+}
+
+// resolveAction resolves the target of the Action against base, expanding templates with
+// args first, so that relative hrefs can be submitted.
+func resolveAction(base string, a hyper.Action, args hyper.Arguments) (hyper.Action, error) {
+	href, err := a.Expand(args)
+	if err != nil {
+		return a, err
+	}
+	if href, err = resolve(base, href); err != nil {
+		return a, err
+	}
+	a.Href, a.Template = href, ""
+	return a, nil
+}
+
+// resolve resolves href against base.
+func resolve(base string, href string) (string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return "", err
+	}
+	return b.ResolveReference(ref).String(), nil
+}
+
+// splitFields splits s at white space. Single or double quotes group words, e.g.
+// q="hello world" results in the field q=hello world.
+func splitFields(s string) ([]string, error) {
+	var fields []string
+	var b strings.Builder
+	inField := false
+	var quote rune
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			b.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote, inField = r, true
+		case r == ' ' || r == '\t':
+			if inField {
+				fields = append(fields, b.String())
+				b.Reset()
+				inField = false
+			}
+		default:
+			b.WriteRune(r)
+			inField = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if inField {
+		fields = append(fields, b.String())
+	}
+	return fields, nil
+}
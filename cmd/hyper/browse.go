@@ -0,0 +1,495 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cognicraft/hyper"
+)
+
+const browseUsage = `Usage: hyper browse <url>
+
+Browses an API interactively. Type help for a list of commands.
+
+Flags:
+`
+
+const browseHelp = `Commands:
+  show                      show the current item
+  links                     list the links of the current item
+  actions                   list the actions of the current item
+  items                     list the sub-items of the current item
+  follow <rel|#> [k=v ...]  follow a link, templated links are expanded with the arguments
+  open <url>                fetch an item
+  do <rel|#>                perform an action, asking for the values of its parameters; the
+                            result is shown, results with a self link become the current item
+                            and empty results reload the current item
+  query <query>             evaluate a query against the current item, e.g. query .links[*].rel
+  back, forward             navigate the history
+  reload                    fetch the current item again
+  help                      show this help
+  quit                      leave the browser
+`
+
+type page struct {
+	url  string
+	item hyper.Item
+}
+
+type browser struct {
+	client  *hyper.Client
+	in      *bufio.Scanner
+	out     io.Writer
+	output  *output
+	history []page
+	current int
+}
+
+func browse(args []string) int {
+	fs := flag.NewFlagSet("hyper browse", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), browseUsage)
+		fs.PrintDefaults()
+		fmt.Fprint(fs.Output(), clientFlagsHelp)
+	}
+	cf := addClientFlags(fs)
+	o := fs.String("o", "json", outputHelp+" of queries")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	out, err := parseOutput(*o)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	c, err := cf.client()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	href, err := cf.url(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	b := &browser{
+		client:  c,
+		output:  out,
+		in:      bufio.NewScanner(os.Stdin),
+		out:     os.Stdout,
+		current: -1,
+	}
+	if err := b.open(href); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	b.show()
+	b.run()
+	return 0
+}
+
+func (b *browser) page() page {
+	return b.history[b.current]
+}
+
+// run reads and executes commands until quit or the end of the input.
+func (b *browser) run() {
+	for {
+		fmt.Fprint(b.out, "hyper> ")
+		if !b.in.Scan() {
+			fmt.Fprintln(b.out)
+			return
+		}
+		line := strings.TrimSpace(b.in.Text())
+		if line == "" {
+			continue
+		}
+		cmd, rest := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			cmd, rest = line[:i], strings.TrimSpace(line[i+1:])
+		}
+		if cmd == "quit" || cmd == "exit" {
+			return
+		}
+		if err := b.exec(cmd, rest); err != nil {
+			fmt.Fprintf(b.out, "error: %v\n", err)
+		}
+	}
+}
+
+func (b *browser) exec(cmd string, rest string) error {
+	switch cmd {
+	case "help", "?":
+		fmt.Fprint(b.out, browseHelp)
+	case "show":
+		b.show()
+	case "links", "ls":
+		b.links()
+	case "actions":
+		b.actions()
+	case "items":
+		b.items()
+	case "follow", "f":
+		fields, err := splitFields(rest)
+		if err != nil {
+			return err
+		}
+		if len(fields) == 0 {
+			return fmt.Errorf("usage: follow <rel|#> [k=v ...]")
+		}
+		return b.follow(fields[0], fields[1:])
+	case "open":
+		if rest == "" {
+			return fmt.Errorf("usage: open <url>")
+		}
+		href, err := resolve(b.page().url, rest)
+		if err != nil {
+			return err
+		}
+		if err := b.open(href); err != nil {
+			return err
+		}
+		b.show()
+	case "do":
+		if rest == "" {
+			return fmt.Errorf("usage: do <rel|#>")
+		}
+		return b.do(rest)
+	case "query", "q":
+		if rest == "" {
+			rest = "."
+		}
+		res, err := hyper.EvalQuery(b.page().item, rest)
+		if err != nil {
+			return err
+		}
+		return b.output.print(b.out, res)
+	case "back", "b":
+		if b.current == 0 {
+			return fmt.Errorf("no previous item")
+		}
+		b.current--
+		b.show()
+	case "forward":
+		if b.current == len(b.history)-1 {
+			return fmt.Errorf("no next item")
+		}
+		b.current++
+		b.show()
+	case "reload", "r":
+		p := b.page()
+		i, err := b.client.FetchContext(context.Background(), p.url)
+		if err != nil {
+			return err
+		}
+		b.history[b.current].item = i
+		b.show()
+	default:
+		return fmt.Errorf("unknown command %q, type help for a list of commands", cmd)
+	}
+	return nil
+}
+
+// open fetches the item at href and makes it the current one. The forward history is discarded.
+func (b *browser) open(href string) error {
+	i, err := b.client.FetchContext(context.Background(), href)
+	if err != nil {
+		return err
+	}
+	b.history = append(b.history[:b.current+1], page{url: href, item: i})
+	b.current = len(b.history) - 1
+	return nil
+}
+
+func (b *browser) follow(ref string, kvs []string) error {
+	p := b.page()
+	l, ok := p.item.Links.FindByRel(ref)
+	if !ok {
+		n, err := strconv.Atoi(ref)
+		if err != nil || n < 1 || n > len(p.item.Links) {
+			return fmt.Errorf("no link %q", ref)
+		}
+		l = p.item.Links[n-1]
+	}
+	args, err := parseArguments(l.Parameters, kvs)
+	if err != nil {
+		return err
+	}
+	href, err := l.Expand(args)
+	if err != nil {
+		return err
+	}
+	if href, err = resolve(p.url, href); err != nil {
+		return err
+	}
+	if err := b.open(href); err != nil {
+		return err
+	}
+	b.show()
+	return nil
+}
+
+func (b *browser) do(ref string) error {
+	p := b.page()
+	a, ok := p.item.Actions.FindByRel(ref)
+	if !ok {
+		n, err := strconv.Atoi(ref)
+		if err != nil || n < 1 || n > len(p.item.Actions) {
+			return fmt.Errorf("no action %q", ref)
+		}
+		a = p.item.Actions[n-1]
+	}
+	if a.Confirmation != "" && !b.confirm(a.Confirmation) {
+		return nil
+	}
+	args := hyper.Arguments{}
+	for _, prm := range a.Parameters {
+		if prm.Type == hyper.TypeHidden || prm.Name == hyper.NameAction || prm.ReadOnly {
+			continue
+		}
+		v, ok, err := b.prompt(prm)
+		if err != nil {
+			return err
+		}
+		if ok {
+			args[prm.Name] = v
+		}
+	}
+	if errs := hyper.Validate(a, hyper.Command{Action: a.Rel, Arguments: args}); len(errs) > 0 {
+		printErrors(b.out, errs)
+		return fmt.Errorf("invalid arguments")
+	}
+	a, err := resolveAction(p.url, a, args)
+	if err != nil {
+		return err
+	}
+	res, err := b.client.Submit(context.Background(), a, args)
+	if err != nil {
+		return err
+	}
+	switch self, ok := res.Links.FindByRel(hyper.RelSelf); {
+	case reflect.DeepEqual(res, hyper.Item{}):
+		// e.g. 204 No Content: the action changed the current item
+		return b.exec("reload", "")
+	case ok && self.Href != "":
+		// e.g. a created resource: navigate to it
+		href, err := resolve(a.Href, self.Href)
+		if err != nil {
+			return err
+		}
+		b.history = append(b.history[:b.current+1], page{url: href, item: res})
+		b.current = len(b.history) - 1
+		b.show()
+	default:
+		b.showItem(a.Href, res)
+	}
+	return nil
+}
+
+// prompt asks for the value of a parameter until it is valid. An empty answer keeps the
+// value of the parameter; ok is false if there is none.
+func (b *browser) prompt(prm hyper.Parameter) (interface{}, bool, error) {
+	label := prm.Label
+	if label == "" {
+		label = prm.Name
+	}
+	if prm.Description != "" {
+		fmt.Fprintf(b.out, "  %s\n", prm.Description)
+	}
+	options := flattenOptions(prm.Options)
+	for k, o := range options {
+		fmt.Fprintf(b.out, "  %d) %s\n", k+1, optionLabel(o))
+	}
+	var hints []string
+	if prm.Type != "" && prm.Type != hyper.TypeText {
+		hints = append(hints, prm.Type)
+	}
+	if prm.Placeholder != "" {
+		hints = append(hints, "e.g. "+prm.Placeholder)
+	}
+	if prm.Multiple {
+		hints = append(hints, "comma separated")
+	}
+	if prm.Value != nil {
+		hints = append(hints, fmt.Sprintf("default %v", prm.Value))
+	}
+	if prm.Required {
+		label += "*"
+	}
+	for {
+		if len(hints) > 0 {
+			fmt.Fprintf(b.out, "%s (%s): ", label, strings.Join(hints, ", "))
+		} else {
+			fmt.Fprintf(b.out, "%s: ", label)
+		}
+		if !b.in.Scan() {
+			return nil, false, fmt.Errorf("aborted")
+		}
+		raw := strings.TrimSpace(b.in.Text())
+		if raw == "" {
+			if prm.Required && prm.Value == nil {
+				fmt.Fprintf(b.out, "  %s is required\n", prm.Name)
+				continue
+			}
+			return nil, false, nil
+		}
+		if len(options) > 0 {
+			raw = optionAnswer(raw, options, prm.Multiple)
+		}
+		v, err := argumentValue(prm, raw)
+		if err != nil {
+			fmt.Fprintf(b.out, "  %v\n", err)
+			continue
+		}
+		errs := hyper.Validate(hyper.Action{Parameters: hyper.Parameters{prm}}, hyper.Command{Arguments: hyper.Arguments{prm.Name: v}})
+		if len(errs) > 0 {
+			printErrors(b.out, errs)
+			continue
+		}
+		return v, true, nil
+	}
+}
+
+// optionAnswer replaces the numbers of options in an answer by their values. Answers for
+// parameters with multiple values are split at commas.
+func optionAnswer(raw string, options []hyper.SelectOption, multiple bool) string {
+	parts := []string{raw}
+	if multiple {
+		parts = strings.Split(raw, ",")
+	}
+	for k, part := range parts {
+		part = strings.TrimSpace(part)
+		if n, err := strconv.Atoi(part); err == nil && n >= 1 && n <= len(options) {
+			part = fmt.Sprint(options[n-1].Value)
+		}
+		parts[k] = part
+	}
+	return strings.Join(parts, ",")
+}
+
+func (b *browser) confirm(msg string) bool {
+	fmt.Fprintf(b.out, "%s [y/N]: ", msg)
+	if !b.in.Scan() {
+		return false
+	}
+	answer := strings.ToLower(strings.TrimSpace(b.in.Text()))
+	return answer == "y" || answer == "yes"
+}
+
+// flattenOptions returns the selectable options, including those of nested groups.
+func flattenOptions(os []hyper.SelectOption) []hyper.SelectOption {
+	var res []hyper.SelectOption
+	for _, o := range os {
+		if len(o.Options) > 0 {
+			res = append(res, flattenOptions(o.Options)...)
+			continue
+		}
+		res = append(res, o)
+	}
+	return res
+}
+
+func optionLabel(o hyper.SelectOption) string {
+	if o.Label != "" {
+		return fmt.Sprintf("%s (%v)", o.Label, o.Value)
+	}
+	return fmt.Sprint(o.Value)
+}
+
+func (b *browser) show() {
+	p := b.page()
+	b.showItem(p.url, p.item)
+}
+
+// showItem prints a summary of the Item that was retrieved from url.
+func (b *browser) showItem(url string, i hyper.Item) {
+	fmt.Fprintln(b.out, url)
+	title := i.Label
+	if title == "" {
+		title = i.ID
+	}
+	if title != "" {
+		fmt.Fprintf(b.out, "  %s", title)
+		if i.Type != "" {
+			fmt.Fprintf(b.out, " [%s]", i.Type)
+		}
+		fmt.Fprintln(b.out)
+	}
+	if i.Description != "" {
+		fmt.Fprintf(b.out, "  %s\n", i.Description)
+	}
+	tw := tabwriter.NewWriter(b.out, 0, 4, 2, ' ', 0)
+	for _, prop := range i.Properties {
+		name := prop.Label
+		if name == "" {
+			name = prop.Name
+		}
+		fmt.Fprintf(tw, "  %s:\t%v %s\n", name, prop.Value, prop.Unit)
+	}
+	tw.Flush()
+	fmt.Fprintf(b.out, "  %d links, %d actions, %d items\n", len(i.Links), len(i.Actions), len(i.Items))
+	if len(i.Errors) > 0 {
+		printErrors(b.out, i.Errors)
+	}
+}
+
+func (b *browser) links() {
+	tw := tabwriter.NewWriter(b.out, 0, 4, 2, ' ', 0)
+	for k, l := range b.page().item.Links {
+		href := l.Href
+		if l.Template != "" {
+			href = l.Template
+		}
+		fmt.Fprintf(tw, "  %d\t%s\t%s\t%s\n", k+1, l.Rel, l.Label, href)
+	}
+	tw.Flush()
+}
+
+func (b *browser) actions() {
+	tw := tabwriter.NewWriter(b.out, 0, 4, 2, ' ', 0)
+	for k, a := range b.page().item.Actions {
+		method := a.Method
+		if method == "" {
+			method = hyper.MethodPOST
+		}
+		href := a.Href
+		if a.Template != "" {
+			href = a.Template
+		}
+		fmt.Fprintf(tw, "  %d\t%s\t%s\t%s %s\n", k+1, a.Rel, a.Label, method, href)
+	}
+	tw.Flush()
+}
+
+func (b *browser) items() {
+	tw := tabwriter.NewWriter(b.out, 0, 4, 2, ' ', 0)
+	for k, i := range b.page().item.Items {
+		fmt.Fprintf(tw, "  %d\t%s\t%s\t%s\t%s\n", k+1, i.ID, i.Rel, i.Type, i.Label)
+	}
+	tw.Flush()
+}
+
+func printErrors(w io.Writer, errs hyper.Errors) {
+	for _, e := range errs {
+		switch {
+		case e.Label != "" && e.Code != "":
+			fmt.Fprintf(w, "  %s: %s (%s)\n", e.Label, e.Message, e.Code)
+		case e.Label != "":
+			fmt.Fprintf(w, "  %s: %s\n", e.Label, e.Message)
+		case e.Code != "":
+			fmt.Fprintf(w, "  %s (%s)\n", e.Message, e.Code)
+		default:
+			fmt.Fprintf(w, "  %s\n", e.Message)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cognicraft/hyper"
+)
+
+func TestBrowse(t *testing.T) {
+	var mu sync.Mutex
+	gets := map[string]int{}
+	touched := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodGet {
+			gets[r.URL.Path]++
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /":
+			hyper.Write(w, http.StatusOK, hyper.Item{
+				Label: fmt.Sprintf("home %d", touched),
+				Links: hyper.Links{{Rel: "self", Href: "/"}, {Rel: "next", Href: "/orders"}},
+				Actions: hyper.Actions{
+					{Rel: "touch", Href: "/touch"},
+					{
+						Rel:  "create",
+						Href: "/orders",
+						Parameters: hyper.Parameters{
+							{Name: "name", Type: hyper.TypeText, Required: true},
+							{
+								Name:     "colors",
+								Type:     "select",
+								Multiple: true,
+								Options:  hyper.SelectOptions{{Value: "red"}, {Value: "green"}, {Value: "blue"}},
+							},
+						},
+					},
+				},
+			})
+		case "GET /orders":
+			hyper.Write(w, http.StatusOK, hyper.Item{Label: "orders"})
+		case "POST /touch":
+			touched++
+			w.WriteHeader(http.StatusNoContent)
+		case "POST /orders":
+			c := hyper.ExtractCommand(r)
+			hyper.Write(w, http.StatusCreated, hyper.Item{
+				Label: fmt.Sprintf("created %v %v", c.Arguments["name"], c.Arguments["colors"]),
+				Links: hyper.Links{{Rel: "self", Href: "/orders/7"}},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+
+	script := strings.Join([]string{
+		"follow next",
+		"back",
+		"forward",
+		"back",
+		"do touch",
+		"do create",
+		"",
+		"foo",
+		"1,3",
+		"quit",
+	}, "\n")
+	out := &bytes.Buffer{}
+	b := &browser{
+		client:  hyper.NewClient(),
+		in:      bufio.NewScanner(strings.NewReader(script)),
+		out:     out,
+		output:  &output{format: "json"},
+		current: -1,
+	}
+	if err := b.open(s.URL + "/"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b.run()
+
+	for _, want := range []string{
+		s.URL + "/orders\n  orders",
+		"home 0",
+		// the empty result of touch reloads the current item
+		"home 1",
+		"name is required",
+		"colors (select, comma separated): ",
+		s.URL + "/orders/7\n  created foo [red blue]",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("want: %q in output, got:\n%s", want, out)
+		}
+	}
+	if want := s.URL + "/orders/7"; want != b.page().url {
+		t.Errorf("want: %s, got: %s", want, b.page().url)
+	}
+	// the result of create replaced the forward history
+	if want, got := 2, len(b.history); want != got {
+		t.Errorf("want: %d pages, got: %d", want, got)
+	}
+	if want, got := 2, gets["/"]; want != got {
+		t.Errorf("want: %d fetches of /, got: %d", want, got)
+	}
+}
+
+func TestOptionAnswer(t *testing.T) {
+	options := []hyper.SelectOption{{Value: "red"}, {Value: "green"}, {Value: "blue"}}
+	tests := []struct {
+		raw      string
+		multiple bool
+		expect   string
+	}{
+		{raw: "2", expect: "green"},
+		{raw: "red", expect: "red"},
+		{raw: "4", expect: "4"},
+		{raw: "1,3", expect: "1,3"},
+		{raw: "1,3", multiple: true, expect: "red,blue"},
+		{raw: "1, green", multiple: true, expect: "red,green"},
+	}
+	for _, test := range tests {
+		if got := optionAnswer(test.raw, options, test.multiple); test.expect != got {
+			t.Errorf("%q: want: %s, got: %s", test.raw, test.expect, got)
+		}
+	}
+}
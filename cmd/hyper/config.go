@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cognicraft/hyper"
+)
+
+// config is the file of named profiles, e.g.
+//
+//	{
+//	  "profiles": {
+//	    "dev": {
+//	      "base-url": "http://localhost:8080/api/",
+//	      "headers": {"X-Tenant": "acme"},
+//	      "timeout": "5s"
+//	    },
+//	    "prod": {
+//	      "base-url": "https://api.example.com/",
+//	      "token": "${PROD_TOKEN}"
+//	    }
+//	  }
+//	}
+//
+// Environment variables in the form $VAR or ${VAR} are expanded in header values, tokens,
+// usernames and passwords.
+type config struct {
+	Profiles map[string]profile `json:"profiles"`
+}
+
+type profile struct {
+	BaseURL  string            `json:"base-url,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Token    string            `json:"token,omitempty"`
+	Username string            `json:"username,omitempty"`
+	Password string            `json:"password,omitempty"`
+	Timeout  string            `json:"timeout,omitempty"`
+}
+
+// configPath returns the path of the config file: $HYPER_CONFIG or hyper/config.json in
+// the user's config directory.
+func configPath() string {
+	if p := os.Getenv("HYPER_CONFIG"); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "hyper", "config.json")
+}
+
+func loadProfile(path string, name string) (profile, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return profile{}, fmt.Errorf("profile %s: %v", name, err)
+	}
+	var cfg config
+	if err := json.Unmarshal(bs, &cfg); err != nil {
+		return profile{}, fmt.Errorf("profile %s: %s: %v", name, path, err)
+	}
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		return profile{}, fmt.Errorf("profile %s: not found in %s", name, path)
+	}
+	return p, nil
+}
+
+// headerFlag collects repeated "Name: value" flags.
+type headerFlag http.Header
+
+func (h headerFlag) String() string {
+	var hs []string
+	for k, vs := range h {
+		for _, v := range vs {
+			hs = append(hs, k+": "+v)
+		}
+	}
+	return strings.Join(hs, ", ")
+}
+
+func (h headerFlag) Set(v string) error {
+	i := strings.Index(v, ":")
+	if i <= 0 {
+		return fmt.Errorf("invalid header %q, expected Name: value", v)
+	}
+	http.Header(h).Add(strings.TrimSpace(v[:i]), strings.TrimSpace(v[i+1:]))
+	return nil
+}
+
+// clientFlags are the flags that configure the Client of every command. Flags take
+// precedence over the profile.
+type clientFlags struct {
+	headers headerFlag
+	token   string
+	user    string
+	timeout time.Duration
+	profile string
+	config  string
+
+	base *url.URL
+}
+
+const clientFlagsHelp = `
+Authentication uses -token, -user or the token, username and password of the profile.
+Without them, the environment variables HYPER_TOKEN, or HYPER_USER and HYPER_PASSWORD,
+are used. Profiles are read from the file given by -config, $HYPER_CONFIG or
+hyper/config.json in the user's config directory; $HYPER_PROFILE selects the default.
+Relative URLs are resolved against the base URL of the profile.
+`
+
+func addClientFlags(fs *flag.FlagSet) *clientFlags {
+	cf := &clientFlags{headers: headerFlag{}}
+	fs.Var(cf.headers, "H", "Header to send, e.g. -H 'Accept-Language: de' (repeatable)")
+	fs.StringVar(&cf.token, "token", "", "Bearer token")
+	fs.StringVar(&cf.user, "user", "", "Basic auth credentials as name:password")
+	fs.DurationVar(&cf.timeout, "timeout", 0, "Timeout of each request, e.g. 10s")
+	fs.StringVar(&cf.profile, "profile", os.Getenv("HYPER_PROFILE"), "Name of the profile to use")
+	fs.StringVar(&cf.config, "config", "", "Path of the profiles config file")
+	return cf
+}
+
+// client creates the Client for the profile and flags, with additional options.
+func (cf *clientFlags) client(extra ...hyper.ClientOption) (*hyper.Client, error) {
+	var p profile
+	if cf.profile != "" {
+		path := cf.config
+		if path == "" {
+			path = configPath()
+		}
+		var err error
+		if p, err = loadProfile(path, cf.profile); err != nil {
+			return nil, err
+		}
+	}
+
+	var opts []hyper.ClientOption
+	if p.BaseURL != "" {
+		base, err := url.Parse(os.ExpandEnv(p.BaseURL))
+		if err != nil || !base.IsAbs() {
+			return nil, fmt.Errorf("profile %s: invalid base-url %q", cf.profile, p.BaseURL)
+		}
+		cf.base = base
+		opts = append(opts, hyper.WithBaseURL(base))
+	}
+
+	timeout := cf.timeout
+	if timeout == 0 && p.Timeout != "" {
+		d, err := time.ParseDuration(p.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: invalid timeout %q", cf.profile, p.Timeout)
+		}
+		timeout = d
+	}
+	if timeout > 0 {
+		opts = append(opts, hyper.WithTimeout(timeout))
+	}
+
+	token, user := cf.token, cf.user
+	if token == "" && user == "" {
+		token = os.ExpandEnv(p.Token)
+		if p.Username != "" {
+			user = os.ExpandEnv(p.Username) + ":" + os.ExpandEnv(p.Password)
+		}
+	}
+	if token == "" && user == "" {
+		token = os.Getenv("HYPER_TOKEN")
+		if u := os.Getenv("HYPER_USER"); u != "" {
+			user = u + ":" + os.Getenv("HYPER_PASSWORD")
+		}
+	}
+	switch {
+	case token != "":
+		opts = append(opts, hyper.WithBearerToken(token, nil))
+	case user != "":
+		name, password, _ := strings.Cut(user, ":")
+		opts = append(opts, hyper.WithBasicAuth(name, password))
+	}
+
+	c := hyper.NewClient(append(opts, extra...)...)
+	for k, v := range p.Headers {
+		c.AdditionalHeader().Set(k, os.ExpandEnv(v))
+	}
+	for k, vs := range cf.headers {
+		c.AdditionalHeader()[k] = vs
+	}
+	return c, nil
+}
+
+// url resolves a URL argument against the base URL of the profile.
+func (cf *clientFlags) url(arg string) (string, error) {
+	u, err := url.Parse(arg)
+	if err != nil {
+		return "", err
+	}
+	if cf.base != nil {
+		u = cf.base.ResolveReference(u)
+	}
+	if !u.IsAbs() {
+		return "", fmt.Errorf("invalid url %q, use an absolute URL or a profile with a base-url", arg)
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cognicraft/hyper"
+)
+
+func TestClientFlags(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		hyper.Write(w, http.StatusOK, hyper.Item{
+			Properties: hyper.Properties{
+				{Name: "path", Value: r.URL.Path},
+				{Name: "authorization", Value: r.Header.Get("Authorization")},
+				{Name: "tenant", Value: r.Header.Get("X-Tenant")},
+				{Name: "language", Value: r.Header.Get("Accept-Language")},
+			},
+		})
+	}))
+	defer s.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	cfg := `{
+  "profiles": {
+    "dev": {
+      "base-url": "` + s.URL + `/api/",
+      "headers": {"X-Tenant": "${TENANT}"},
+      "token": "${DEV_TOKEN}",
+      "timeout": "10ms"
+    },
+    "basic": {
+      "base-url": "` + s.URL + `/api/",
+      "username": "$DEV_USER",
+      "password": "secret"
+    },
+    "broken": {
+      "base-url": "/relative"
+    }
+  }
+}`
+	if err := os.WriteFile(path, []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HYPER_CONFIG", path)
+	t.Setenv("HYPER_TOKEN", "")
+	t.Setenv("HYPER_USER", "")
+	t.Setenv("HYPER_PASSWORD", "")
+	t.Setenv("TENANT", "acme")
+	t.Setenv("DEV_TOKEN", "t0k3n")
+	t.Setenv("DEV_USER", "alice")
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		url     string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "profile",
+			args: []string{"-profile", "dev"},
+			url:  "orders",
+			want: map[string]string{"path": "/api/orders", "authorization": "Bearer t0k3n", "tenant": "acme"},
+		},
+		{
+			name: "profile-from-env",
+			env:  map[string]string{"HYPER_PROFILE": "dev"},
+			url:  "orders",
+			want: map[string]string{"path": "/api/orders", "authorization": "Bearer t0k3n", "tenant": "acme"},
+		},
+		{
+			name: "profile-basic",
+			args: []string{"-profile", "basic"},
+			url:  "/other",
+			want: map[string]string{"path": "/other", "authorization": "Basic YWxpY2U6c2VjcmV0"},
+		},
+		{
+			name: "flags-over-profile",
+			args: []string{"-profile", "dev", "-token", "flag", "-H", "X-Tenant: other", "-H", "Accept-Language: de"},
+			url:  "orders",
+			want: map[string]string{"authorization": "Bearer flag", "tenant": "other", "language": "de"},
+		},
+		{
+			name: "user-flag-over-profile-token",
+			args: []string{"-profile", "dev", "-user", "bob:pw"},
+			url:  "orders",
+			want: map[string]string{"authorization": "Basic Ym9iOnB3"},
+		},
+		{
+			name:    "profile-timeout",
+			args:    []string{"-profile", "dev"},
+			url:     "slow",
+			wantErr: "Timeout",
+		},
+		{
+			name: "timeout-flag-over-profile",
+			args: []string{"-profile", "dev", "-timeout", "5s"},
+			url:  "slow",
+			want: map[string]string{"path": "/api/slow"},
+		},
+		{
+			name: "env-token",
+			env:  map[string]string{"HYPER_TOKEN": "from-env"},
+			url:  s.URL + "/x",
+			want: map[string]string{"authorization": "Bearer from-env"},
+		},
+		{
+			name: "env-user",
+			env:  map[string]string{"HYPER_USER": "carol", "HYPER_PASSWORD": "pw"},
+			url:  s.URL + "/x",
+			want: map[string]string{"authorization": "Basic Y2Fyb2w6cHc="},
+		},
+		{
+			name: "profile-over-env",
+			env:  map[string]string{"HYPER_TOKEN": "from-env"},
+			args: []string{"-profile", "dev"},
+			url:  "orders",
+			want: map[string]string{"authorization": "Bearer t0k3n"},
+		},
+		{
+			name:    "missing-profile",
+			args:    []string{"-profile", "prod"},
+			wantErr: "profile prod: not found",
+		},
+		{
+			name:    "missing-config",
+			args:    []string{"-profile", "dev", "-config", filepath.Join(dir, "missing.json")},
+			wantErr: "profile dev:",
+		},
+		{
+			name:    "invalid-base-url",
+			args:    []string{"-profile", "broken"},
+			wantErr: "invalid base-url",
+		},
+		{
+			name:    "relative-url-without-profile",
+			url:     "orders",
+			wantErr: "invalid url",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("HYPER_PROFILE", "")
+			for k, v := range test.env {
+				t.Setenv(k, v)
+			}
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			cf := addClientFlags(fs)
+			if err := fs.Parse(test.args); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			i, err := fetch(cf, test.url)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("want: error containing %q, got: %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for k, want := range test.want {
+				if got := hyper.Query(i, ".properties[name="+k+"].value"); got != want {
+					t.Errorf("%s: want: %q, got: %v", k, want, got)
+				}
+			}
+		})
+	}
+}
+
+func fetch(cf *clientFlags, arg string) (hyper.Item, error) {
+	c, err := cf.client()
+	if err != nil {
+		return hyper.Item{}, err
+	}
+	u, err := cf.url(arg)
+	if err != nil {
+		return hyper.Item{}, err
+	}
+	return c.Fetch(u)
+}
+
+func TestHeaderFlag(t *testing.T) {
+	h := headerFlag{}
+	for _, v := range []string{"X-A: 1", "x-a:2", "Accept: a:b"} {
+		if err := h.Set(v); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := http.Header(h).Values("X-A"); strings.Join(got, ",") != "1,2" {
+		t.Errorf("want: 1,2, got: %v", got)
+	}
+	if got := http.Header(h).Get("Accept"); got != "a:b" {
+		t.Errorf("want: a:b, got: %v", got)
+	}
+	for _, v := range []string{"X-A", ": 1"} {
+		if err := h.Set(v); err == nil {
+			t.Errorf("want: error for %q, got: nil", v)
+		}
+	}
+}
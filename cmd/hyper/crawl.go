@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cognicraft/hyper"
+)
+
+const crawlUsage = `Usage: hyper crawl [flags] <url>
+
+Crawls an API breadth-first, starting with the item at url, and reports the status and
+errors of every resource as well as broken and cyclic links. Exits with 1 if url or any
+link is broken.
+
+Flags:
+`
+
+// crawlResource is a crawled resource in the report.
+type crawlResource struct {
+	URL    string       `json:"url"`
+	Depth  int          `json:"depth"`
+	Status int          `json:"status,omitempty"`
+	ID     string       `json:"id,omitempty"`
+	Label  string       `json:"label,omitempty"`
+	Error  string       `json:"error,omitempty"`
+	Errors hyper.Errors `json:"errors,omitempty"`
+	Links  []crawlLink  `json:"links,omitempty"`
+
+	parent *crawlResource
+}
+
+// broken reports whether the resource could not be fetched.
+func (r *crawlResource) broken() bool {
+	return r.Error != ""
+}
+
+// crawlLink is a link from one resource to another.
+type crawlLink struct {
+	Rel    string `json:"rel"`
+	Href   string `json:"href"`
+	Broken bool   `json:"broken,omitempty"`
+	Cyclic bool   `json:"cyclic,omitempty"`
+	// Skipped is set for links that were not followed because of the filters or limits.
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+type crawlLinkRef struct {
+	From string `json:"from"`
+	Rel  string `json:"rel"`
+	To   string `json:"to"`
+}
+
+type crawlReport struct {
+	Start     string           `json:"start"`
+	Resources []*crawlResource `json:"resources"`
+	Broken    []crawlLinkRef   `json:"broken,omitempty"`
+	Cyclic    []crawlLinkRef   `json:"cyclic,omitempty"`
+}
+
+type crawler struct {
+	client      *hyper.Client
+	rels        map[string]bool
+	hosts       map[string]bool
+	concurrency int
+	maxDepth    int
+	max         int
+}
+
+func crawl(args []string) int {
+	fs := flag.NewFlagSet("hyper crawl", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), crawlUsage)
+		fs.PrintDefaults()
+		fmt.Fprint(fs.Output(), clientFlagsHelp)
+	}
+	cf := addClientFlags(fs)
+	var rels, hosts listFlag
+	fs.Var(&rels, "rel", "Follow only links with these rels (repeatable, comma separated)")
+	fs.Var(&hosts, "host", "Follow only links to these hosts, * for any (default: the host of url)")
+	concurrency := fs.Int("concurrency", 4, "Maximum number of concurrent requests")
+	maxDepth := fs.Int("max-depth", 0, "Maximum number of links from url, 0 for unlimited")
+	max := fs.Int("max", 1000, "Maximum number of resources")
+	format := fs.String("format", "json", "Output format: json or dot")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	if *format != "json" && *format != "dot" {
+		fmt.Fprintf(os.Stderr, "hyper crawl: unknown format %q\n", *format)
+		return 2
+	}
+	c, err := cf.client()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "hyper crawl: %v\n", err)
+		return 2
+	}
+	href, err := cf.url(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "hyper crawl: %v\n", err)
+		return 2
+	}
+	start, _ := url.Parse(href)
+	if len(hosts) == 0 {
+		hosts = listFlag{start.Host}
+	}
+
+	cr := &crawler{
+		client:      c,
+		rels:        set(rels),
+		hosts:       set(hosts),
+		concurrency: *concurrency,
+		maxDepth:    *maxDepth,
+		max:         *max,
+	}
+	if cr.concurrency < 1 {
+		cr.concurrency = 1
+	}
+	report := cr.crawl(context.Background(), start.String())
+
+	switch *format {
+	case "dot":
+		writeDOT(os.Stdout, report)
+	default:
+		bs, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Println(string(bs))
+	}
+	if len(report.Broken) > 0 || report.Resources[0].broken() {
+		return 1
+	}
+	return 0
+}
+
+func set(ss []string) map[string]bool {
+	if len(ss) == 0 {
+		return nil
+	}
+	m := map[string]bool{}
+	for _, s := range ss {
+		m[s] = true
+	}
+	return m
+}
+
+// crawl visits the resources level by level. The resources of a level are fetched
+// concurrently, the report lists them in the order in which they were discovered.
+func (cr *crawler) crawl(ctx context.Context, start string) *crawlReport {
+	report := &crawlReport{Start: start}
+	seen := map[string]*crawlResource{}
+	level := []*crawlResource{{URL: start}}
+	seen[start] = level[0]
+	for depth := 0; len(level) > 0; depth++ {
+		report.Resources = append(report.Resources, level...)
+		items := cr.fetchAll(ctx, level)
+
+		var next []*crawlResource
+		for k, res := range level {
+			for _, l := range items[k] {
+				target, ok := seen[l.Href]
+				if !ok && cr.follow(l) && (cr.maxDepth == 0 || depth < cr.maxDepth) && len(seen) < cr.max {
+					target = &crawlResource{URL: l.Href, Depth: depth + 1, parent: res}
+					seen[l.Href] = target
+					next = append(next, target)
+				}
+				if target == nil {
+					l.Skipped = true
+				} else {
+					// links to the resource itself, e.g. rel=self, are not cycles
+					l.Cyclic = target != res && isAncestor(target, res)
+				}
+				res.Links = append(res.Links, l)
+			}
+		}
+		level = next
+	}
+
+	// links are broken if their target was not found; this is only known after the crawl
+	for _, res := range report.Resources {
+		for k, l := range res.Links {
+			ref := crawlLinkRef{From: res.URL, Rel: l.Rel, To: l.Href}
+			if target, ok := seen[l.Href]; l.Broken || ok && target.broken() {
+				res.Links[k].Broken = true
+				report.Broken = append(report.Broken, ref)
+			}
+			if l.Cyclic {
+				report.Cyclic = append(report.Cyclic, ref)
+			}
+		}
+	}
+	return report
+}
+
+// fetchAll fetches the resources concurrently and returns their links.
+func (cr *crawler) fetchAll(ctx context.Context, level []*crawlResource) [][]crawlLink {
+	links := make([][]crawlLink, len(level))
+	sem := make(chan struct{}, cr.concurrency)
+	var wg sync.WaitGroup
+	for k, res := range level {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(k int, res *crawlResource) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			links[k] = cr.fetch(ctx, res)
+		}(k, res)
+	}
+	wg.Wait()
+	return links
+}
+
+func (cr *crawler) fetch(ctx context.Context, res *crawlResource) []crawlLink {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, res.URL, nil)
+	if err != nil {
+		res.Error = err.Error()
+		return nil
+	}
+	resp, err := cr.client.Do(req)
+	if err != nil {
+		res.Error = err.Error()
+		var re *hyper.ResponseError
+		if errors.As(err, &re) {
+			res.Status = re.StatusCode
+			res.Errors = re.Errors
+		}
+		return nil
+	}
+	res.Status = resp.StatusCode
+	res.ID = resp.Item.ID
+	res.Label = resp.Item.Label
+	base, _ := url.Parse(res.URL)
+
+	var links []crawlLink
+	all := func(hyper.Item) bool { return true }
+	for _, m := range hyper.SearchItems(resp.Item, all, hyper.SearchOptions{All: true}) {
+		res.Errors = append(res.Errors, m.Item.Errors...)
+		for _, l := range m.Item.Links {
+			if l.Href == "" {
+				// templated links cannot be followed without arguments
+				continue
+			}
+			ref, err := url.Parse(l.Href)
+			if err != nil {
+				links = append(links, crawlLink{Rel: l.Rel, Href: l.Href, Broken: true})
+				continue
+			}
+			u := base.ResolveReference(ref)
+			u.Fragment = ""
+			links = append(links, crawlLink{Rel: l.Rel, Href: u.String()})
+		}
+	}
+	return links
+}
+
+// follow reports whether a link passes the rel and host filters.
+func (cr *crawler) follow(l crawlLink) bool {
+	if l.Broken {
+		return false
+	}
+	if cr.rels != nil && !cr.rels[l.Rel] {
+		return false
+	}
+	u, err := url.Parse(l.Href)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	return cr.hosts["*"] || cr.hosts[u.Host]
+}
+
+// isAncestor reports whether a is r or one of the resources r was discovered from.
+func isAncestor(a *crawlResource, r *crawlResource) bool {
+	for ; r != nil; r = r.parent {
+		if a == r {
+			return true
+		}
+	}
+	return false
+}
+
+// writeDOT writes the report as a Graphviz graph. Broken resources and links are red,
+// cyclic links are dashed.
+func writeDOT(w io.Writer, report *crawlReport) {
+	fmt.Fprintln(w, "digraph hyper {")
+	fmt.Fprintln(w, "  node [shape=box];")
+	for _, res := range report.Resources {
+		label := res.URL
+		if res.ID != "" {
+			label = res.ID + "\n" + res.URL
+		}
+		if res.Status != 0 {
+			label += fmt.Sprintf("\n%d", res.Status)
+		}
+		attrs := []string{"label=" + dotQuote(label)}
+		if res.broken() {
+			attrs = append(attrs, "color=red")
+		}
+		fmt.Fprintf(w, "  %s [%s];\n", dotQuote(res.URL), strings.Join(attrs, ", "))
+	}
+	for _, res := range report.Resources {
+		links := append([]crawlLink(nil), res.Links...)
+		sort.SliceStable(links, func(i, j int) bool { return links[i].Href < links[j].Href })
+		for _, l := range links {
+			if l.Skipped {
+				continue
+			}
+			attrs := []string{"label=" + dotQuote(l.Rel)}
+			if l.Broken {
+				attrs = append(attrs, "color=red")
+			}
+			if l.Cyclic {
+				attrs = append(attrs, "style=dashed")
+			}
+			fmt.Fprintf(w, "  %s -> %s [%s];\n", dotQuote(res.URL), dotQuote(l.Href), strings.Join(attrs, ", "))
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/cognicraft/hyper"
+)
+
+func TestCrawl(t *testing.T) {
+	links := map[string]hyper.Links{
+		"/": {
+			{Rel: "self", Href: "/"},
+			{Rel: "next", Href: "/a"},
+			{Rel: "missing", Href: "/missing"},
+			{Rel: "external", Href: "http://example.com/"},
+		},
+		"/a": {
+			{Rel: "self", Href: "/a"},
+			{Rel: "up", Href: "/"},
+			{Rel: "next", Href: "b"},
+		},
+		"/b": {
+			{Rel: "self", Href: "/b#top"},
+			{Rel: "prev", Href: "/a"},
+		},
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ls, ok := links[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		hyper.Write(w, http.StatusOK, hyper.Item{Label: r.URL.Path, Links: ls})
+	}))
+	defer s.Close()
+	u, _ := url.Parse(s.URL)
+
+	cr := &crawler{
+		client:      hyper.NewClient(),
+		hosts:       set([]string{u.Host}),
+		concurrency: 2,
+		max:         100,
+	}
+	report := cr.crawl(context.Background(), s.URL+"/")
+
+	var resources []string
+	for _, res := range report.Resources {
+		resources = append(resources, res.URL[len(s.URL):])
+	}
+	if want := []string{"/", "/a", "/missing", "/b"}; !reflect.DeepEqual(want, resources) {
+		t.Errorf("want: %v, got: %v", want, resources)
+	}
+	ref := func(from, rel, to string) crawlLinkRef {
+		return crawlLinkRef{From: s.URL + from, Rel: rel, To: s.URL + to}
+	}
+	if want := []crawlLinkRef{ref("/", "missing", "/missing")}; !reflect.DeepEqual(want, report.Broken) {
+		t.Errorf("want: %v, got: %v", want, report.Broken)
+	}
+	if want := []crawlLinkRef{ref("/a", "up", "/"), ref("/b", "prev", "/a")}; !reflect.DeepEqual(want, report.Cyclic) {
+		t.Errorf("want: %v, got: %v", want, report.Cyclic)
+	}
+	if l := report.Resources[0].Links[3]; !l.Skipped {
+		t.Errorf("want: %s skipped, got: %#v", l.Href, l)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/cognicraft/hyper"
+)
+
+const doUsage = `Usage: hyper do [flags] <url> <action-rel> [key=value ...]
+
+Fetches the item at url and performs its action with the given rel. The arguments are
+checked against the parameters of the action before they are submitted. Values of number
+parameters are sent as numbers, files are given as key=@path and repeated keys or comma
+separated values of multiple parameters are sent as lists.
+
+The resulting item is printed to stdout, errors are printed to stderr. The exit code is
+0 on success, 3, 4 or 5 for responses with a 3xx, 4xx or 5xx status, 4 for arguments that
+are invalid, 1 for other errors and 2 for invalid usage. Redirects are followed, so 3 is
+only used for redirections that cannot be followed, e.g. 300 Multiple Choices.
+
+Flags:
+`
+
+// Exit codes of hyper do.
+const (
+	exitOK          = 0
+	exitError       = 1
+	exitUsage       = 2
+	exitRedirection = 3
+	exitClientError = 4
+	exitServerError = 5
+)
+
+func do(args []string) int {
+	return runDo(args, os.Stdout, os.Stderr)
+}
+
+// runDo runs hyper do, printing the resulting item to stdout and errors to stderr.
+func runDo(args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("hyper do", flag.ExitOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), doUsage)
+		fs.PrintDefaults()
+		fmt.Fprint(fs.Output(), clientFlagsHelp)
+	}
+	cf := addClientFlags(fs)
+	dryRun := fs.Bool("n", false, "Only check the arguments, do not submit")
+	o := fs.String("o", "json", outputHelp)
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return exitUsage
+	}
+	out, err := parseOutput(*o)
+	if err != nil {
+		fmt.Fprintf(stderr, "hyper do: %v\n", err)
+		return exitUsage
+	}
+	// status is the status of the final response, i.e. after redirects have been followed
+	status := 0
+	c, err := cf.client(hyper.WithMiddleware(func(next http.RoundTripper) http.RoundTripper {
+		return hyper.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			resp, err := next.RoundTrip(r)
+			if resp != nil {
+				status = resp.StatusCode
+			}
+			return resp, err
+		})
+	}))
+	if err != nil {
+		fmt.Fprintf(stderr, "hyper do: %v\n", err)
+		return exitUsage
+	}
+	href, err := cf.url(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "hyper do: %v\n", err)
+		return exitUsage
+	}
+	rel := fs.Arg(1)
+	ctx := context.Background()
+
+	item, err := c.FetchContext(ctx, href)
+	if err != nil {
+		return fail(stderr, err, status)
+	}
+	a, ok := item.Actions.FindByRel(rel)
+	if !ok {
+		fmt.Fprintf(stderr, "hyper do: no action %q, available actions:\n", rel)
+		for _, a := range item.Actions {
+			fmt.Fprintf(stderr, "  %s\n", a.Rel)
+		}
+		return exitUsage
+	}
+	cargs, err := parseArguments(a.Parameters, fs.Args()[2:])
+	if err != nil {
+		fmt.Fprintf(stderr, "hyper do: %v\n", err)
+		return exitUsage
+	}
+	if errs := hyper.Validate(a, hyper.Command{Action: a.Rel, Arguments: cargs}); len(errs) > 0 {
+		printErrors(stderr, errs)
+		return exitClientError
+	}
+	if *dryRun {
+		return exitOK
+	}
+	a, err = resolveAction(href, a, cargs)
+	if err != nil {
+		return fail(stderr, err, 0)
+	}
+	status = 0
+	res, err := c.Submit(ctx, a, cargs)
+	if err != nil {
+		return fail(stderr, err, status)
+	}
+	if err := out.print(stdout, res); err != nil {
+		return fail(stderr, err, 0)
+	}
+	if len(res.Errors) > 0 {
+		printErrors(stderr, res.Errors)
+	}
+	if status/100 == 3 {
+		// a redirection that has not been followed, e.g. 300 Multiple Choices
+		fmt.Fprintf(stderr, "hyper do: %d %s\n", status, http.StatusText(status))
+		return exitRedirection
+	}
+	return exitOK
+}
+
+// fail prints the error and returns the exit code for it. status is the status of the
+// response that caused the error, if any.
+func fail(w io.Writer, err error, status int) int {
+	var re *hyper.ResponseError
+	if !errors.As(err, &re) {
+		fmt.Fprintf(w, "hyper do: %v\n", err)
+		if status/100 == 3 {
+			return exitRedirection
+		}
+		return exitError
+	}
+	fmt.Fprintf(w, "hyper do: %d %s\n", re.StatusCode, http.StatusText(re.StatusCode))
+	printErrors(w, re.Errors)
+	return exitCode(re.StatusCode)
+}
+
+// exitCode maps the class of an HTTP status to an exit code.
+func exitCode(status int) int {
+	switch status / 100 {
+	case 2:
+		return exitOK
+	case 3:
+		return exitRedirection
+	case 4:
+		return exitClientError
+	case 5:
+		return exitServerError
+	default:
+		return exitError
+	}
+}
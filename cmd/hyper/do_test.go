@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cognicraft/hyper"
+)
+
+func TestDo(t *testing.T) {
+	var mu sync.Mutex
+	posts := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			mu.Lock()
+			posts++
+			mu.Unlock()
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /order":
+			hyper.Write(w, http.StatusOK, hyper.Item{
+				Label: "order",
+				Actions: hyper.Actions{
+					{Rel: "pay", Href: "/pay", Parameters: hyper.Parameters{{Name: "amount", Type: hyper.TypeNumber, Required: true}}},
+					{Rel: "reject", Href: "/reject"},
+					{Rel: "fail", Href: "/fail"},
+					{Rel: "choose", Href: "/choose"},
+					{Rel: "redirect", Href: "/redirect"},
+				},
+			})
+		case "POST /pay":
+			c := hyper.ExtractCommand(r)
+			hyper.Write(w, http.StatusOK, hyper.Item{Label: fmt.Sprintf("paid %v", c.Arguments["amount"])})
+		case "POST /reject":
+			hyper.WriteError(w, http.StatusConflict, fmt.Errorf("already paid"))
+		case "POST /fail":
+			hyper.WriteError(w, http.StatusInternalServerError, fmt.Errorf("boom"))
+		case "POST /choose":
+			hyper.Write(w, http.StatusMultipleChoices, hyper.Item{Label: "choose"})
+		case "POST /redirect":
+			http.Redirect(w, r, "/order", http.StatusSeeOther)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+	t.Setenv("HYPER_PROFILE", "")
+	t.Setenv("HYPER_TOKEN", "")
+	t.Setenv("HYPER_USER", "")
+
+	order := s.URL + "/order"
+	tests := []struct {
+		name         string
+		args         []string
+		expectCode   int
+		expectStdout string
+		expectStderr string
+		expectPosts  int
+	}{
+		{
+			name:         "ok",
+			args:         []string{order, "pay", "amount=3"},
+			expectCode:   exitOK,
+			expectStdout: `"label": "paid 3"`,
+			expectPosts:  1,
+		},
+		{
+			name:        "dry-run",
+			args:        []string{"-n", order, "pay", "amount=3"},
+			expectCode:  exitOK,
+			expectPosts: 0,
+		},
+		{
+			name:         "required",
+			args:         []string{order, "pay"},
+			expectCode:   exitClientError,
+			expectStderr: "amount is required",
+		},
+		{
+			name:         "invalid-type",
+			args:         []string{order, "pay", "amount=much"},
+			expectCode:   exitClientError,
+			expectStderr: "amount must be a number",
+		},
+		{
+			name:         "invalid-argument",
+			args:         []string{order, "pay", "amount"},
+			expectCode:   exitUsage,
+			expectStderr: "expected key=value",
+		},
+		{
+			name:         "no-action",
+			args:         []string{order, "ship"},
+			expectCode:   exitUsage,
+			expectStderr: "no action \"ship\", available actions:\n  pay\n  reject\n  fail\n  choose\n  redirect\n",
+		},
+		{
+			name:         "client-error",
+			args:         []string{order, "reject"},
+			expectCode:   exitClientError,
+			expectStderr: "409 Conflict\n  already paid",
+			expectPosts:  1,
+		},
+		{
+			name:         "server-error",
+			args:         []string{order, "fail"},
+			expectCode:   exitServerError,
+			expectStderr: "500 Internal Server Error\n  boom",
+			expectPosts:  1,
+		},
+		{
+			name:         "redirection",
+			args:         []string{order, "choose"},
+			expectCode:   exitRedirection,
+			expectStdout: `"label": "choose"`,
+			expectStderr: "300 Multiple Choices",
+			expectPosts:  1,
+		},
+		{
+			name:         "redirect-followed",
+			args:         []string{order, "redirect"},
+			expectCode:   exitOK,
+			expectStdout: `"label": "order"`,
+			expectPosts:  1,
+		},
+		{
+			name:         "not-found",
+			args:         []string{s.URL + "/missing", "pay"},
+			expectCode:   exitClientError,
+			expectStderr: "404 Not Found",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mu.Lock()
+			posts = 0
+			mu.Unlock()
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			code := runDo(test.args, stdout, stderr)
+			if test.expectCode != code {
+				t.Errorf("want: %d, got: %d (%s)", test.expectCode, code, stderr)
+			}
+			if !strings.Contains(stdout.String(), test.expectStdout) {
+				t.Errorf("want: %q in stdout, got: %s", test.expectStdout, stdout)
+			}
+			if !strings.Contains(stderr.String(), test.expectStderr) {
+				t.Errorf("want: %q in stderr, got: %s", test.expectStderr, stderr)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if test.expectPosts != posts {
+				t.Errorf("want: %d posts, got: %d", test.expectPosts, posts)
+			}
+		})
+	}
+}
@@ -1,28 +1,95 @@
 package main
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/cognicraft/hyper"
 )
 
+const usage = `Usage:
+  hyper [flags] <url>                 fetch an item and print the result of the query
+  hyper crawl [flags] <url>           crawl the links of an API, see hyper crawl -h
+  hyper browse [flags] <url>          browse an API interactively, see hyper browse -h
+  hyper do [flags] <url> <rel> [k=v]  perform an action, see hyper do -h
+
+Flags:
+`
+
 func main() {
-	q := flag.String("q", ".", "Query")
-	flag.Parse()
+	log.SetFlags(0)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "crawl":
+			os.Exit(crawl(os.Args[2:]))
+		case "do":
+			os.Exit(do(os.Args[2:]))
+		case "browse":
+			os.Exit(browse(os.Args[2:]))
+		case "help":
+			get([]string{"-h"})
+		}
+	}
+	get(os.Args[1:])
+}
+
+func get(args []string) {
+	fs := flag.NewFlagSet("hyper", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+		fmt.Fprint(fs.Output(), clientFlagsHelp)
+	}
+	cf := addClientFlags(fs)
+	q := fs.String("q", ".", "Query, e.g. .links[rel=next].href")
+	o := fs.String("o", "json", outputHelp)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	out, err := parseOutput(*o)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	c := hyper.NewClient()
-	item, err := c.Fetch(flag.Args()[0])
+	c, err := cf.client()
+	if err != nil {
+		log.Fatal(err)
+	}
+	href, err := cf.url(fs.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	item, err := c.Fetch(href)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	res := hyper.Query(item, *q)
-	bs, err := json.MarshalIndent(res, "", "  ")
+	res, err := hyper.EvalQuery(item, *q)
 	if err != nil {
 		log.Fatal(err)
 	}
-	fmt.Println(string(bs))
+	if err := out.print(os.Stdout, res); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// listFlag is a flag that can be repeated and also accepts comma separated values.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(v string) error {
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*l = append(*l, s)
+		}
+	}
+	return nil
 }
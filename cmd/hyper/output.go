@@ -0,0 +1,390 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/cognicraft/hyper"
+)
+
+const outputHelp = "Output format: json, yaml, table, raw or template=<text/template>"
+
+// output prints values in one of the supported formats:
+//
+//	json      indented JSON
+//	yaml      YAML with the order of the JSON members
+//	table     the properties of an item as name, value and unit columns, lists of objects
+//	          with one column per member and other objects as key and value columns
+//	raw       scalars without quotes, one line per element of a list, for shell pipelines
+//	template  a text/template that is applied to the value with JSON member names, e.g.
+//	          template='{{range .items}}{{.id}}{{"\n"}}{{end}}'
+type output struct {
+	format string
+	tmpl   *template.Template
+}
+
+func parseOutput(s string) (*output, error) {
+	switch {
+	case s == "json" || s == "yaml" || s == "table" || s == "raw":
+		return &output{format: s}, nil
+	case strings.HasPrefix(s, "template="):
+		t, err := template.New("output").Funcs(template.FuncMap{
+			"json": func(v interface{}) (string, error) {
+				bs, err := json.Marshal(v)
+				return string(bs), err
+			},
+		}).Parse(strings.TrimPrefix(s, "template="))
+		if err != nil {
+			return nil, err
+		}
+		return &output{format: "template", tmpl: t}, nil
+	case s == "template":
+		return nil, fmt.Errorf("missing template, use -o template=<text/template>")
+	default:
+		return nil, fmt.Errorf("unknown output format %q", s)
+	}
+}
+
+func (o *output) print(w io.Writer, v interface{}) error {
+	switch o.format {
+	case "yaml":
+		ov, err := ordered(v)
+		if err != nil {
+			return err
+		}
+		for _, l := range yamlLines(ov) {
+			fmt.Fprintln(w, l)
+		}
+		return nil
+	case "raw":
+		ov, err := ordered(v)
+		if err != nil {
+			return err
+		}
+		return printRaw(w, ov)
+	case "table":
+		return printTable(w, v)
+	case "template":
+		ov, err := ordered(v)
+		if err != nil {
+			return err
+		}
+		return o.tmpl.Execute(w, plain(ov))
+	default:
+		bs, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(bs))
+		return err
+	}
+}
+
+// orderedObject is a JSON object that keeps the order of its members.
+type orderedObject []orderedField
+
+type orderedField struct {
+	key   string
+	value interface{}
+}
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(jsonString(f.key))
+		buf.WriteByte(':')
+		bs, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(bs)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// ordered converts v to its JSON representation made of orderedObject, []interface{},
+// string, json.Number, bool and nil.
+func ordered(v interface{}) (interface{}, error) {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(bs))
+	dec.UseNumber()
+	return decodeOrdered(dec)
+}
+
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t {
+	case json.Delim('{'):
+		obj := orderedObject{}
+		for dec.More() {
+			k, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, orderedField{key: k.(string), value: v})
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		_, err := dec.Token()
+		return list, err
+	default:
+		return t, nil
+	}
+}
+
+// plain converts orderedObjects to maps, e.g. for templates.
+func plain(v interface{}) interface{} {
+	switch v := v.(type) {
+	case orderedObject:
+		m := make(map[string]interface{}, len(v))
+		for _, f := range v {
+			m[f.key] = plain(f.value)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, e := range v {
+			l[i] = plain(e)
+		}
+		return l
+	default:
+		return v
+	}
+}
+
+func isComposite(v interface{}) bool {
+	switch v := v.(type) {
+	case orderedObject:
+		return len(v) > 0
+	case []interface{}:
+		return len(v) > 0
+	default:
+		return false
+	}
+}
+
+// yamlLines renders v as YAML lines without indentation.
+func yamlLines(v interface{}) []string {
+	switch v := v.(type) {
+	case orderedObject:
+		if len(v) == 0 {
+			return []string{"{}"}
+		}
+		var lines []string
+		for _, f := range v {
+			key := yamlString(f.key)
+			if !isComposite(f.value) {
+				lines = append(lines, key+": "+yamlLines(f.value)[0])
+				continue
+			}
+			lines = append(lines, key+":")
+			for _, l := range yamlLines(f.value) {
+				lines = append(lines, "  "+l)
+			}
+		}
+		return lines
+	case []interface{}:
+		if len(v) == 0 {
+			return []string{"[]"}
+		}
+		var lines []string
+		for _, e := range v {
+			for i, l := range yamlLines(e) {
+				if i == 0 {
+					lines = append(lines, "- "+l)
+				} else {
+					lines = append(lines, "  "+l)
+				}
+			}
+		}
+		return lines
+	case nil:
+		return []string{"null"}
+	case string:
+		return []string{yamlString(v)}
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+// yamlString quotes strings that would otherwise be read as another type or break the
+// syntax. JSON strings are valid double-quoted YAML strings.
+func yamlString(s string) string {
+	switch strings.ToLower(s) {
+	case "", "null", "~", "true", "false", "yes", "no", "y", "n", "on", "off":
+		return jsonString(s)
+	}
+	if strings.TrimSpace(s) != s || strings.ContainsAny(s, ":#{}[],&*?|<>=!%@`'\"\\\n\t") ||
+		strings.HasPrefix(s, "-") || yamlImplicit.MatchString(s) {
+		return jsonString(s)
+	}
+	return s
+}
+
+// yamlImplicit matches the plain scalars that YAML 1.1 or 1.2 parsers resolve to ints, floats
+// or timestamps, e.g. 0x10, 0o17, 1_000, 1e3, .inf, .nan and 2024-01-01.
+var yamlImplicit = regexp.MustCompile(`^[-+]?(0b[01_]+|0o?[0-7_]+|0x[0-9a-fA-F_]+|[0-9][0-9_]*([eE][-+]?[0-9]+)?|([0-9][0-9_]*)?\.[0-9_]*([eE][-+]?[0-9]+)?|\.(inf|Inf|INF))$|^\.(nan|NaN|NAN)$|^[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}([Tt ].*)?$`)
+
+func jsonString(s string) string {
+	bs, _ := json.Marshal(s)
+	return string(bs)
+}
+
+// printRaw prints scalars without quotes and composite values as compact JSON. The
+// elements of a list are printed on separate lines.
+func printRaw(w io.Writer, v interface{}) error {
+	if l, ok := v.([]interface{}); ok {
+		for _, e := range l {
+			fmt.Fprintln(w, rawString(e))
+		}
+		return nil
+	}
+	_, err := fmt.Fprintln(w, rawString(v))
+	return err
+}
+
+func rawString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case orderedObject, []interface{}:
+		bs, _ := json.Marshal(v)
+		return string(bs)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// printTable prints the properties of Items, lists of objects with a column per member and
+// other objects with key and value columns.
+func printTable(w io.Writer, v interface{}) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	defer tw.Flush()
+	switch v := v.(type) {
+	case hyper.Item:
+		printProperties(tw, v.Properties)
+		return nil
+	case hyper.Properties:
+		printProperties(tw, v)
+		return nil
+	case hyper.Property:
+		printProperties(tw, hyper.Properties{v})
+		return nil
+	case []interface{}:
+		// e.g. the result of .properties[?(.value > 0)]
+		ps := hyper.Properties{}
+		for _, e := range v {
+			if p, ok := e.(hyper.Property); ok {
+				ps = append(ps, p)
+			}
+		}
+		if len(v) > 0 && len(ps) == len(v) {
+			printProperties(tw, ps)
+			return nil
+		}
+	}
+	ov, err := ordered(v)
+	if err != nil {
+		return err
+	}
+	switch ov := ov.(type) {
+	case []interface{}:
+		var columns []string
+		seen := map[string]bool{}
+		for _, e := range ov {
+			obj, ok := e.(orderedObject)
+			if !ok {
+				continue
+			}
+			for _, f := range obj {
+				if !seen[f.key] {
+					seen[f.key] = true
+					columns = append(columns, f.key)
+				}
+			}
+		}
+		if len(columns) == 0 {
+			tw.Flush()
+			return printRaw(w, ov)
+		}
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(columns, "\t")))
+		for _, e := range ov {
+			obj, _ := e.(orderedObject)
+			cells := make([]string, len(columns))
+			for _, f := range obj {
+				for k, c := range columns {
+					if c == f.key {
+						cells[k] = tableCell(f.value)
+					}
+				}
+			}
+			fmt.Fprintln(tw, strings.Join(cells, "\t"))
+		}
+	case orderedObject:
+		fmt.Fprintln(tw, "KEY\tVALUE")
+		for _, f := range ov {
+			fmt.Fprintf(tw, "%s\t%s\n", f.key, tableCell(f.value))
+		}
+	default:
+		fmt.Fprintln(tw, rawString(ov))
+	}
+	return nil
+}
+
+// printProperties prints the visible properties by label or name, with the display value
+// if there is one, and their unit.
+func printProperties(w io.Writer, ps hyper.Properties) {
+	fmt.Fprintln(w, "NAME\tVALUE\tUNIT")
+	for _, p := range ps {
+		if p.Render == hyper.RenderNone {
+			continue
+		}
+		name := p.Label
+		if name == "" {
+			name = p.Name
+		}
+		value := p.Display
+		if value == "" {
+			ov, _ := ordered(p.Value)
+			value = tableCell(ov)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, value, p.Unit)
+	}
+}
+
+// tableCell formats a value for a single cell.
+func tableCell(v interface{}) string {
+	return strings.NewReplacer("\t", " ", "\n", " ").Replace(rawString(v))
+}
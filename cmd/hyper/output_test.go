@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cognicraft/hyper"
+)
+
+func TestOutput(t *testing.T) {
+	item := hyper.Item{
+		Label: "Order",
+		ID:    "order:1",
+		Properties: hyper.Properties{
+			{Name: "total", Label: "Total", Value: 12.5, Unit: "EUR"},
+			{Name: "state", Value: "open"},
+			{Name: "secret", Value: "x", Render: hyper.RenderNone},
+			{Name: "paid", Value: "2024-01-01", Display: "1 Jan 2024"},
+		},
+	}
+	scalars := []interface{}{
+		"0x10", "0o17", "017", "0b101", "1_000", "1e3", "+1", "1.5", ".5", ".inf", "-.Inf", ".NaN",
+		"y", "N", "Yes", "off", "TRUE", "null", "~", "", "2024-01-01", "2024-01-01T10:00:00Z",
+		"-a", "a: b", " a", "plain", "x10", "v1.5", "1.2.3",
+		3, 1.5, true, nil,
+	}
+	tests := []struct {
+		format string
+		value  interface{}
+		want   string
+	}{
+		{
+			format: "json",
+			value:  hyper.Item{Label: "a", Items: hyper.Items{{ID: "b"}}},
+			want:   "{\n  \"label\": \"a\",\n  \"items\": [\n    {\n      \"id\": \"b\"\n    }\n  ]\n}\n",
+		},
+		{
+			format: "yaml",
+			value:  item,
+			want: "label: Order\n" +
+				"id: \"order:1\"\n" +
+				"properties:\n" +
+				"  - label: Total\n" +
+				"    name: total\n" +
+				"    value: 12.5\n" +
+				"    unit: EUR\n" +
+				"  - name: state\n" +
+				"    value: open\n" +
+				"  - render: none\n" +
+				"    name: secret\n" +
+				"    value: x\n" +
+				"  - name: paid\n" +
+				"    value: \"2024-01-01\"\n" +
+				"    display: 1 Jan 2024\n",
+		},
+		{
+			format: "yaml",
+			value:  scalars,
+			want: "- \"0x10\"\n- \"0o17\"\n- \"017\"\n- \"0b101\"\n- \"1_000\"\n- \"1e3\"\n- \"+1\"\n" +
+				"- \"1.5\"\n- \".5\"\n- \".inf\"\n- \"-.Inf\"\n- \".NaN\"\n" +
+				"- \"y\"\n- \"N\"\n- \"Yes\"\n- \"off\"\n- \"TRUE\"\n- \"null\"\n- \"~\"\n- \"\"\n" +
+				"- \"2024-01-01\"\n- \"2024-01-01T10:00:00Z\"\n" +
+				"- \"-a\"\n- \"a: b\"\n- \" a\"\n- plain\n- x10\n- v1.5\n- 1.2.3\n" +
+				"- 3\n- 1.5\n- true\n- null\n",
+		},
+		{
+			format: "yaml",
+			value:  map[string]interface{}{"empty": []interface{}{}, "object": map[string]interface{}{}},
+			want:   "empty: []\nobject: {}\n",
+		},
+		{
+			format: "table",
+			value:  item,
+			want: "NAME   VALUE       UNIT\n" +
+				"Total  12.5        EUR\n" +
+				"state  open        \n" +
+				"paid   1 Jan 2024  \n",
+		},
+		{
+			format: "table",
+			value:  []interface{}{map[string]interface{}{"id": "a", "n": 1}, map[string]interface{}{"id": "b", "x": true}},
+			want: "ID  N  X\n" +
+				"a   1  \n" +
+				"b      true\n",
+		},
+		{
+			format: "table",
+			value:  map[string]interface{}{"a": "x\ty", "b": []interface{}{1, 2}},
+			want: "KEY  VALUE\n" +
+				"a    x y\n" +
+				"b    [1,2]\n",
+		},
+		{
+			format: "raw",
+			value:  []interface{}{"a b", 1, nil, true, map[string]interface{}{"k": "v"}},
+			want:   "a b\n1\n\ntrue\n{\"k\":\"v\"}\n",
+		},
+		{
+			format: "raw",
+			value:  "0x10",
+			want:   "0x10\n",
+		},
+		{
+			format: `template={{.label}}:{{range .properties}} {{.name}}={{.value}}{{end}}{{"\n"}}`,
+			value:  hyper.Item{Label: "Order", Properties: hyper.Properties{{Name: "total", Value: 12.5}, {Name: "state", Value: "open"}}},
+			want:   "Order: total=12.5 state=open\n",
+		},
+		{
+			format: `template={{json .items}}`,
+			value:  hyper.Item{Items: hyper.Items{{ID: "a"}}},
+			want:   `[{"id":"a"}]`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			o, err := parseOutput(test.format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var buf bytes.Buffer
+			if err := o.print(&buf, test.value); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := buf.String(); got != test.want {
+				t.Errorf("want: %q, got: %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestParseOutputError(t *testing.T) {
+	for _, s := range []string{"xml", "template", "template={{.label"} {
+		if _, err := parseOutput(s); err == nil {
+			t.Errorf("want: error for %q, got: nil", s)
+		}
+	}
+}
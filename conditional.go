@@ -0,0 +1,121 @@
+package hyper
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Validators identify the current version of a representation (RFC 7232, 2).
+type Validators struct {
+	ETag         string
+	LastModified time.Time
+}
+
+// ItemETag computes a strong entity tag from the JSON representation of the Item.
+func ItemETag(i Item) string {
+	bs, _ := json.Marshal(i)
+	sum := sha1.Sum(bs)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// WriteConditional writes a hyper-item like Write, but answers GET and HEAD requests with
+// 304 Not Modified if the If-None-Match or If-Modified-Since header of the request show that
+// the client already has the current representation. An empty ETag is computed with ItemETag.
+func WriteConditional(w http.ResponseWriter, r *http.Request, status int, i Item, v Validators) {
+	if v.ETag == "" {
+		v.ETag = ItemETag(i)
+	}
+	v.setHeader(w.Header())
+	if status == http.StatusOK && (r.Method == http.MethodGet || r.Method == http.MethodHead) && notModified(r, v) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	Write(w, status, i)
+}
+
+// CheckPreconditions evaluates the If-Match and If-Unmodified-Since headers of a request against
+// the Validators of the current representation. It is meant to be called before a state changing
+// Command is executed. If a precondition fails, 412 Precondition Failed is written and false is
+// returned.
+func CheckPreconditions(w http.ResponseWriter, r *http.Request, v Validators) bool {
+	if im := r.Header.Get(HeaderIfMatch); im != "" {
+		if !matchETag(im, v.ETag, false) {
+			WriteError(w, http.StatusPreconditionFailed, fmt.Errorf("precondition failed: %s %s", HeaderIfMatch, im))
+			return false
+		}
+		return true
+	}
+	if ius := r.Header.Get(HeaderIfUnmodifiedSince); ius != "" && !v.LastModified.IsZero() {
+		t, err := http.ParseTime(ius)
+		if err == nil && v.LastModified.Truncate(time.Second).After(t) {
+			WriteError(w, http.StatusPreconditionFailed, fmt.Errorf("precondition failed: %s %s", HeaderIfUnmodifiedSince, ius))
+			return false
+		}
+	}
+	return true
+}
+
+func (v Validators) setHeader(h http.Header) {
+	if v.ETag != "" {
+		h.Set(HeaderETag, v.ETag)
+	}
+	if !v.LastModified.IsZero() {
+		h.Set(HeaderLastModified, v.LastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// notModified evaluates If-None-Match and, in its absence, If-Modified-Since (RFC 7232, 6).
+func notModified(r *http.Request, v Validators) bool {
+	if inm := r.Header.Get(HeaderIfNoneMatch); inm != "" {
+		return matchETag(inm, v.ETag, true)
+	}
+	if ims := r.Header.Get(HeaderIfModifiedSince); ims != "" && !v.LastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		return err == nil && !v.LastModified.Truncate(time.Second).After(t)
+	}
+	return false
+}
+
+// matchETag reports whether etag is matched by the list of entity tags of a conditional header.
+// The weak comparison function is used for If-None-Match, the strong one for If-Match.
+func matchETag(list string, etag string, weak bool) bool {
+	if strings.TrimSpace(list) == "*" {
+		return etag != ""
+	}
+	if etag == "" {
+		return false
+	}
+	for _, candidate := range splitETags(list) {
+		switch {
+		case weak && strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/"):
+			return true
+		case !weak && candidate == etag && !strings.HasPrefix(etag, "W/"):
+			return true
+		}
+	}
+	return false
+}
+
+// splitETags splits a comma separated list of entity tags. Commas within quotes are retained.
+func splitETags(list string) []string {
+	var etags []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(list); i++ {
+		switch list[i] {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				etags = append(etags, strings.TrimSpace(list[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(etags, strings.TrimSpace(list[start:]))
+}
@@ -0,0 +1,194 @@
+package hyper_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cognicraft/hyper"
+)
+
+func TestWriteConditional(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		method       string
+		header       http.Header
+		validators   hyper.Validators
+		expectStatus int
+	}{
+		{
+			name:         "unconditional",
+			validators:   hyper.Validators{ETag: `"v1"`},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name:         "if-none-match",
+			header:       http.Header{hyper.HeaderIfNoneMatch: {`"v1"`}},
+			validators:   hyper.Validators{ETag: `"v1"`},
+			expectStatus: http.StatusNotModified,
+		},
+		{
+			name:         "if-none-match-weak",
+			header:       http.Header{hyper.HeaderIfNoneMatch: {`W/"v1"`}},
+			validators:   hyper.Validators{ETag: `"v1"`},
+			expectStatus: http.StatusNotModified,
+		},
+		{
+			name:         "if-none-match-list",
+			header:       http.Header{hyper.HeaderIfNoneMatch: {`"v0", "a,b", "v1"`}},
+			validators:   hyper.Validators{ETag: `"v1"`},
+			expectStatus: http.StatusNotModified,
+		},
+		{
+			name:         "if-none-match-any",
+			header:       http.Header{hyper.HeaderIfNoneMatch: {"*"}},
+			validators:   hyper.Validators{ETag: `"v1"`},
+			expectStatus: http.StatusNotModified,
+		},
+		{
+			name:         "if-none-match-changed",
+			header:       http.Header{hyper.HeaderIfNoneMatch: {`"v0"`}},
+			validators:   hyper.Validators{ETag: `"v1"`},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name:         "if-none-match-head",
+			method:       http.MethodHead,
+			header:       http.Header{hyper.HeaderIfNoneMatch: {`"v1"`}},
+			validators:   hyper.Validators{ETag: `"v1"`},
+			expectStatus: http.StatusNotModified,
+		},
+		{
+			name:         "if-none-match-post",
+			method:       http.MethodPost,
+			header:       http.Header{hyper.HeaderIfNoneMatch: {`"v1"`}},
+			validators:   hyper.Validators{ETag: `"v1"`},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name:         "if-modified-since",
+			header:       http.Header{hyper.HeaderIfModifiedSince: {modified.Format(http.TimeFormat)}},
+			validators:   hyper.Validators{ETag: `"v1"`, LastModified: modified.Add(500 * time.Millisecond)},
+			expectStatus: http.StatusNotModified,
+		},
+		{
+			name:         "if-modified-since-changed",
+			header:       http.Header{hyper.HeaderIfModifiedSince: {modified.Add(-time.Hour).Format(http.TimeFormat)}},
+			validators:   hyper.Validators{ETag: `"v1"`, LastModified: modified},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name: "if-none-match-precedes-if-modified-since",
+			header: http.Header{
+				hyper.HeaderIfNoneMatch:     {`"v0"`},
+				hyper.HeaderIfModifiedSince: {modified.Format(http.TimeFormat)},
+			},
+			validators:   hyper.Validators{ETag: `"v1"`, LastModified: modified},
+			expectStatus: http.StatusOK,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, "/orders/1", nil)
+			for k, vs := range test.header {
+				r.Header[k] = vs
+			}
+			w := httptest.NewRecorder()
+			hyper.WriteConditional(w, r, http.StatusOK, hyper.Item{Label: "Order 1"}, test.validators)
+			if test.expectStatus != w.Code {
+				t.Errorf("want: %d, got: %d", test.expectStatus, w.Code)
+			}
+			if want, got := test.validators.ETag, w.Header().Get(hyper.HeaderETag); want != got {
+				t.Errorf("want: %s, got: %s", want, got)
+			}
+			if test.expectStatus == http.StatusNotModified && w.Body.Len() > 0 {
+				t.Errorf("want: no body, got: %s", w.Body)
+			}
+		})
+	}
+}
+
+func TestWriteConditionalItemETag(t *testing.T) {
+	i := hyper.Item{Label: "Order 1"}
+	r := httptest.NewRequest(http.MethodGet, "/orders/1", nil)
+	r.Header.Set(hyper.HeaderIfNoneMatch, hyper.ItemETag(i))
+	w := httptest.NewRecorder()
+	hyper.WriteConditional(w, r, http.StatusOK, i, hyper.Validators{})
+	if want := http.StatusNotModified; want != w.Code {
+		t.Errorf("want: %d, got: %d", want, w.Code)
+	}
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		header     http.Header
+		validators hyper.Validators
+		expectOK   bool
+	}{
+		{
+			name:       "unconditional",
+			validators: hyper.Validators{ETag: `"v1"`},
+			expectOK:   true,
+		},
+		{
+			name:       "if-match",
+			header:     http.Header{hyper.HeaderIfMatch: {`"v0", "v1"`}},
+			validators: hyper.Validators{ETag: `"v1"`},
+			expectOK:   true,
+		},
+		{
+			name:       "if-match-any",
+			header:     http.Header{hyper.HeaderIfMatch: {"*"}},
+			validators: hyper.Validators{ETag: `"v1"`},
+			expectOK:   true,
+		},
+		{
+			name:       "if-match-changed",
+			header:     http.Header{hyper.HeaderIfMatch: {`"v0"`}},
+			validators: hyper.Validators{ETag: `"v1"`},
+			expectOK:   false,
+		},
+		{
+			name:       "if-match-weak",
+			header:     http.Header{hyper.HeaderIfMatch: {`W/"v1"`}},
+			validators: hyper.Validators{ETag: `W/"v1"`},
+			expectOK:   false,
+		},
+		{
+			name:       "if-unmodified-since",
+			header:     http.Header{hyper.HeaderIfUnmodifiedSince: {modified.Format(http.TimeFormat)}},
+			validators: hyper.Validators{LastModified: modified},
+			expectOK:   true,
+		},
+		{
+			name:       "if-unmodified-since-changed",
+			header:     http.Header{hyper.HeaderIfUnmodifiedSince: {modified.Add(-time.Hour).Format(http.TimeFormat)}},
+			validators: hyper.Validators{LastModified: modified},
+			expectOK:   false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/orders/1", nil)
+			for k, vs := range test.header {
+				r.Header[k] = vs
+			}
+			w := httptest.NewRecorder()
+			ok := hyper.CheckPreconditions(w, r, test.validators)
+			if test.expectOK != ok {
+				t.Errorf("want: %t, got: %t", test.expectOK, ok)
+			}
+			if !ok && w.Code != http.StatusPreconditionFailed {
+				t.Errorf("want: %d, got: %d", http.StatusPreconditionFailed, w.Code)
+			}
+		})
+	}
+}
@@ -5,6 +5,12 @@ const (
 	TypeHidden = "hidden"
 	// TypeText is used for parameters of type text/string
 	TypeText = "text"
+	// TypeNumber is used for parameters of type number
+	TypeNumber = "number"
+	// TypeRange is used for numeric parameters that are rendered as a slider
+	TypeRange = "range"
+	// TypeFile is used for parameters that upload files (see File)
+	TypeFile = "file"
 )
 
 const (
@@ -13,3 +19,8 @@ const (
 	// RenderTransclude is used on links or items to signal that these should be embedded within the current view.
 	RenderTransclude = "transclude"
 )
+
+const (
+	// RelSelf is used on links that refer to the item itself
+	RelSelf = "self"
+)
@@ -1,5 +1,15 @@
 package hyper
 
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+)
+
 // Error .
 type Error struct {
 	Label       string `json:"label,omitempty"`
@@ -10,3 +20,120 @@ type Error struct {
 
 // Errors .
 type Errors []Error
+
+// Error codes of a StatusError.
+const (
+	CodeMalformedRequest     = "malformed-request"
+	CodeRequestTooLarge      = "request-too-large"
+	CodeUnsupportedMediaType = "unsupported-media-type"
+)
+
+// StatusError is an error that is associated with an HTTP status code.
+// Its ErrCode is written as the Code of the Error by WriteError.
+type StatusError struct {
+	Status  int
+	ErrCode string
+	Err     error
+}
+
+func (e *StatusError) Error() string {
+	return e.Err.Error()
+}
+
+// Code returns the ErrCode.
+func (e *StatusError) Code() string {
+	return e.ErrCode
+}
+
+// StatusCode returns the Status.
+func (e *StatusError) StatusCode() int {
+	return e.Status
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorStatus returns the HTTP status code associated with err, or fallback if there is none.
+func ErrorStatus(err error, fallback int) int {
+	type statusCoder interface {
+		StatusCode() int
+	}
+	var sc statusCoder
+	if errors.As(err, &sc) {
+		return sc.StatusCode()
+	}
+	return fallback
+}
+
+// ResponseError is returned by the Client for responses with a status code of 400 or above.
+// It carries the Errors of the hyper-item that was written with WriteError. Bodies of other
+// content types, e.g. plain JSON or text produced by a proxy, are turned into a single Error.
+type ResponseError struct {
+	StatusCode  int
+	ContentType string
+	Item        Item
+	Errors      Errors
+}
+
+func (e *ResponseError) Error() string {
+	var buf bytes.Buffer
+	buf.WriteString(strconv.Itoa(e.StatusCode))
+	if text := http.StatusText(e.StatusCode); text != "" {
+		buf.WriteString(" ")
+		buf.WriteString(text)
+	}
+	for i, err := range e.Errors {
+		if i == 0 {
+			buf.WriteString(": ")
+		} else {
+			buf.WriteString("; ")
+		}
+		buf.WriteString(err.Message)
+		if err.Code != "" {
+			buf.WriteString(" (")
+			buf.WriteString(err.Code)
+			buf.WriteString(")")
+		}
+	}
+	return buf.String()
+}
+
+// maxErrorBodySize limits how much of an error response is read.
+const maxErrorBodySize = 64 << 10
+
+func newResponseError(resp *http.Response) *ResponseError {
+	e := &ResponseError{
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get(HeaderContentType),
+	}
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return e
+	}
+	if json.Unmarshal(body, &e.Item) == nil && len(e.Item.Errors) > 0 {
+		e.Errors = e.Item.Errors
+		return e
+	}
+	e.Item = Item{}
+	var generic struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+		Code    string `json:"code"`
+	}
+	if json.Unmarshal(body, &generic) == nil {
+		msg := generic.Message
+		if msg == "" {
+			msg = generic.Error
+		}
+		if msg != "" {
+			e.Errors = Errors{{Message: msg, Code: generic.Code}}
+			return e
+		}
+	}
+	if ct, _ := ExtractContentType(resp.Header); ct.MediaType() == "text/plain" {
+		e.Errors = Errors{{Message: string(body)}}
+	}
+	return e
+}
@@ -0,0 +1,184 @@
+package hyper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultMultipartMemory is the number of bytes of a multipart/form-data body that are kept in
+// memory by ReadCommand. Larger uploads are stored in temporary files. It is well below the
+// DefaultMaxCommandSize, so that uploads are not held in memory as a whole.
+const DefaultMultipartMemory = 1 << 20
+
+// File is a file in the Arguments of a Command. On the server it refers to an uploaded file of
+// a multipart/form-data request, on the client it provides the content of a file to upload with
+// Client.Submit. The content is not buffered by the File itself but read when it is opened.
+type File struct {
+	Filename    string
+	Size        int64
+	ContentType string
+	open        func() (io.ReadCloser, error)
+}
+
+// NewFile creates a File whose content is provided by open, e.g. to upload a file from disk:
+//
+//	hyper.NewFile("report.pdf", "application/pdf", func() (io.ReadCloser, error) {
+//		return os.Open("report.pdf")
+//	})
+func NewFile(filename string, contentType string, open func() (io.ReadCloser, error)) *File {
+	return &File{
+		Filename:    filename,
+		Size:        -1,
+		ContentType: contentType,
+		open:        open,
+	}
+}
+
+func newMultipartFile(fh *multipart.FileHeader) *File {
+	return &File{
+		Filename:    fh.Filename,
+		Size:        fh.Size,
+		ContentType: fh.Header.Get(HeaderContentType),
+		open: func() (io.ReadCloser, error) {
+			return fh.Open()
+		},
+	}
+}
+
+// Open opens the content of the File for reading. The caller must close it.
+func (f *File) Open() (io.ReadCloser, error) {
+	if f.open == nil {
+		return nil, fmt.Errorf("open %s: no content", f.Filename)
+	}
+	return f.open()
+}
+
+// MarshalJSON encodes the description of the File, not its content.
+func (f *File) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Filename    string `json:"filename"`
+		Size        int64  `json:"size,omitempty"`
+		ContentType string `json:"content-type,omitempty"`
+	}{f.Filename, f.Size, f.ContentType})
+}
+
+// File returns the File for key or nil if there is none.
+func (a Arguments) File(key string) *File {
+	if fs := a.Files(key); len(fs) > 0 {
+		return fs[0]
+	}
+	return nil
+}
+
+// Files returns all Files for key.
+func (a Arguments) Files(key string) []*File {
+	switch v := a[key].(type) {
+	case *File:
+		return []*File{v}
+	case []*File:
+		return v
+	default:
+		return nil
+	}
+}
+
+func setMultipartArguments(c *Command, form *multipart.Form) {
+	setFormArguments(c, form.Value)
+	for n, fhs := range form.File {
+		if len(fhs) == 1 {
+			c.Arguments[n] = newMultipartFile(fhs[0])
+			continue
+		}
+		fs := make([]*File, 0, len(fhs))
+		for _, fh := range fhs {
+			fs = append(fs, newMultipartFile(fh))
+		}
+		c.Arguments[n] = fs
+	}
+}
+
+// encodeMultipart streams args as multipart/form-data. Files are read only while the body is sent.
+func encodeMultipart(args Arguments) (io.ReadCloser, string) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	body := &multipartBody{
+		pr: pr,
+		write: func() {
+			pw.CloseWithError(writeMultipart(mw, args))
+		},
+	}
+	return body, mw.FormDataContentType()
+}
+
+// multipartBody starts writing the multipart body when it is first read, so that nothing is
+// left running and no file is left open if the request is never sent. Closing the body stops
+// the writer.
+type multipartBody struct {
+	once  sync.Once
+	pr    *io.PipeReader
+	write func()
+}
+
+func (b *multipartBody) Read(p []byte) (int, error) {
+	b.once.Do(func() {
+		go b.write()
+	})
+	return b.pr.Read(p)
+}
+
+func (b *multipartBody) Close() error {
+	return b.pr.Close()
+}
+
+func writeMultipart(mw *multipart.Writer, args Arguments) error {
+	names := make([]string, 0, len(args))
+	for n := range args {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if fs := args.Files(n); fs != nil {
+			for _, f := range fs {
+				if err := writeMultipartFile(mw, n, f); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		for _, v := range formValues(Arguments{n: args[n]})[n] {
+			if err := mw.WriteField(n, v); err != nil {
+				return err
+			}
+		}
+	}
+	return mw.Close()
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func writeMultipartFile(mw *multipart.Writer, name string, f *File) error {
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(name), quoteEscaper.Replace(f.Filename)))
+	ct := f.ContentType
+	if ct == "" {
+		ct = "application/octet-stream"
+	}
+	h.Set(HeaderContentType, ct)
+	w, err := mw.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = io.Copy(w, rc)
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -14,7 +15,26 @@ import (
 // HTTP headers as registered with IANA.
 // See: https://tools.ietf.org/html/rfc7231
 const (
-	HeaderContentType = "Content-Type" // RFC 7231, 3.1.1.5
+	HeaderAccept            = "Accept"              // RFC 7231, 5.3.2
+	HeaderAcceptLanguage    = "Accept-Language"     // RFC 7231, 5.3.5
+	HeaderAge               = "Age"                 // RFC 7234, 5.1
+	HeaderAllow             = "Allow"               // RFC 7231, 7.4.1
+	HeaderAuthorization     = "Authorization"       // RFC 7235, 4.2
+	HeaderCacheControl      = "Cache-Control"       // RFC 7234, 5.2
+	HeaderContentLocation   = "Content-Location"    // RFC 7231, 3.1.4.2
+	HeaderContentType       = "Content-Type"        // RFC 7231, 3.1.1.5
+	HeaderDate              = "Date"                // RFC 7231, 7.1.1.2
+	HeaderETag              = "ETag"                // RFC 7232, 2.3
+	HeaderExpires           = "Expires"             // RFC 7234, 5.3
+	HeaderIfMatch           = "If-Match"            // RFC 7232, 3.1
+	HeaderIfModifiedSince   = "If-Modified-Since"   // RFC 7232, 3.3
+	HeaderIfNoneMatch       = "If-None-Match"       // RFC 7232, 3.2
+	HeaderIfRange           = "If-Range"            // RFC 7233, 3.2
+	HeaderIfUnmodifiedSince = "If-Unmodified-Since" // RFC 7232, 3.4
+	HeaderLastModified      = "Last-Modified"       // RFC 7232, 2.2
+	HeaderLocation          = "Location"            // RFC 7231, 7.1.2
+	HeaderRetryAfter        = "Retry-After"         // RFC 7231, 7.1.3
+	HeaderVary              = "Vary"                // RFC 7231, 7.1.4
 )
 
 // HTTP content types
@@ -23,8 +43,13 @@ const (
 	ContentTypeHyperItemUTF8 = "application/vnd.hyper-item+json;charset=UTF-8" // https://github.com/mdemuth/hyper-item
 	ContentTypeJSON          = "application/json"                              // https://tools.ietf.org/html/rfc8259
 	ContentTypeURLEncoded    = "application/x-www-form-urlencoded"             // http://www.w3.org/TR/html
+	ContentTypeMultipartForm = "multipart/form-data"                           // https://tools.ietf.org/html/rfc7578
 )
 
+// AcceptHyperItem is the Accept header sent by the Client. It prefers hyper-items
+// but falls back to plain JSON.
+const AcceptHyperItem = ContentTypeHyperItem + ", " + ContentTypeJSON + ";q=0.9"
+
 // Write writes a hyper-item to the response writer with the given status code.
 func Write(w http.ResponseWriter, status int, i Item) {
 	w.Header().Set(HeaderContentType, ContentTypeHyperItemUTF8)
@@ -57,42 +82,152 @@ func ActionParameter(value string) Parameter {
 	}
 }
 
+// ExtractCommand reads the Command from the body of the request. Uploaded files of
+// multipart/form-data bodies are available as *File (see Arguments.File). It is lenient: bodies of
+// unknown content types are read as JSON and failures result in a partial or empty Command.
+// Use ReadCommand to learn about failures.
 func ExtractCommand(r *http.Request) Command {
+	c, _ := readCommand(r, 0, false)
+	return c
+}
+
+// DefaultMaxCommandSize is the maximum size of a request body read by ReadCommand.
+const DefaultMaxCommandSize = 10 << 20
+
+// ReadCommand reads the Command from the body of the request like ExtractCommand, but reports
+// failures as *StatusError: 415 Unsupported Media Type for content types other than JSON,
+// ContentTypeURLEncoded and ContentTypeMultipartForm, 413 Request Entity Too Large for bodies that exceed the
+// DefaultMaxCommandSize and 400 Bad Request for malformed bodies.
+func ReadCommand(r *http.Request) (Command, error) {
+	return ReadCommandLimit(r, DefaultMaxCommandSize)
+}
+
+// ReadCommandLimit is like ReadCommand but reads at most maxBytes of the body.
+// A maxBytes <= 0 disables the limit.
+func ReadCommandLimit(r *http.Request, maxBytes int64) (Command, error) {
+	return readCommand(r, maxBytes, true)
+}
+
+func readCommand(r *http.Request, maxBytes int64, strict bool) (Command, error) {
 	c := MakeCommand()
-	ct := r.Header.Get(HeaderContentType)
-	switch ct {
-	case ContentTypeURLEncoded:
-		body, err := ioutil.ReadAll(r.Body)
-		if err != nil {
-			return c
+	if r.Body == nil || r.Body == http.NoBody {
+		return c, nil
+	}
+	ct, _ := ExtractContentType(r.Header)
+	body := &limitedReader{r: r.Body, n: maxBytes}
+	var err error
+	switch {
+	case ct.MediaType() == ContentTypeURLEncoded:
+		err = decodeURLEncodedCommand(body, &c)
+	case ct.MediaType() == ContentTypeMultipartForm:
+		r.Body = limitedReadCloser{body, r.Body}
+		err = r.ParseMultipartForm(DefaultMultipartMemory)
+		if err == nil {
+			setMultipartArguments(&c, r.MultipartForm)
 		}
-		values, err := url.ParseQuery(string(body))
-		if err != nil {
-			return c
+	case ct.MediaType() == "" || ct.IsJSON() || !strict:
+		err = decodeJSONCommand(body, &c)
+	default:
+		return c, &StatusError{
+			Status:  http.StatusUnsupportedMediaType,
+			ErrCode: CodeUnsupportedMediaType,
+			Err:     fmt.Errorf("unsupported content type: %s", ct.MediaType()),
 		}
-		c.Action = values.Get(NameAction)
-		for n, vs := range values {
-			if n == NameAction {
-				continue
-			}
-			if len(vs) == 1 {
-				c.Arguments[n] = vs[0]
-			} else {
-				c.Arguments[n] = vs
-			}
+	}
+	switch {
+	case err == nil:
+		return c, nil
+	case body.exceeded:
+		return c, &StatusError{
+			Status:  http.StatusRequestEntityTooLarge,
+			ErrCode: CodeRequestTooLarge,
+			Err:     fmt.Errorf("request body exceeds %d bytes", maxBytes),
 		}
-		return c
 	default:
-		err := json.NewDecoder(r.Body).Decode(&c.Arguments)
-		if err != nil {
-			return c
+		return c, &StatusError{
+			Status:  http.StatusBadRequest,
+			ErrCode: CodeMalformedRequest,
+			Err:     err,
+		}
+	}
+}
+
+func decodeURLEncodedCommand(r io.Reader, c *Command) error {
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read: %v", err)
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return fmt.Errorf("parse: %v", err)
+	}
+	setFormArguments(c, values)
+	return nil
+}
+
+func setFormArguments(c *Command, values url.Values) {
+	c.Action = values.Get(NameAction)
+	for n, vs := range values {
+		if n == NameAction {
+			continue
 		}
-		if p := c.Arguments.String(NameAction); p != "" {
-			delete(c.Arguments, NameAction)
-			c.Action = p
+		if len(vs) == 1 {
+			c.Arguments[n] = vs[0]
+		} else {
+			c.Arguments[n] = vs
 		}
 	}
-	return c
+}
+
+func decodeJSONCommand(r io.Reader, c *Command) error {
+	err := json.NewDecoder(r).Decode(&c.Arguments)
+	if c.Arguments == nil {
+		c.Arguments = Arguments{}
+	}
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("decode: %v", err)
+	}
+	if p := c.Arguments.String(NameAction); p != "" {
+		delete(c.Arguments, NameAction)
+		c.Action = p
+	}
+	return nil
+}
+
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// limitedReader fails once more than n bytes are read. A n <= 0 disables the limit.
+type limitedReader struct {
+	r        io.Reader
+	n        int64
+	read     int64
+	exceeded bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		return l.r.Read(p)
+	}
+	if l.read >= l.n+1 {
+		l.exceeded = true
+		return 0, fmt.Errorf("request body too large")
+	}
+	if max := l.n + 1 - l.read; int64(len(p)) > max {
+		p = p[:max]
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.n {
+		l.exceeded = true
+		return n, fmt.Errorf("request body too large")
+	}
+	return n, err
 }
 
 func MakeCommand() Command {
@@ -176,7 +311,8 @@ func (a Arguments) Bool(key string) bool {
 func ExtractContentType(h http.Header) (ContentType, error) {
 	v := h.Get(HeaderContentType)
 	ct := ContentType{}
-	return ct, ct.Parse(v)
+	err := ct.Parse(v)
+	return ct, err
 }
 
 type ContentType struct {
@@ -190,14 +326,14 @@ func (ct *ContentType) Parse(v string) error {
 	if sIndex < 0 {
 		return nil
 	}
-	ct.Type = strings.TrimSpace(v[:sIndex])
+	ct.Type = strings.ToLower(strings.TrimSpace(v[:sIndex]))
 	v = v[sIndex+1:]
 	pIndex := strings.Index(v, ";")
 	if pIndex < 0 {
-		ct.Subtype = v
+		ct.Subtype = strings.ToLower(strings.TrimSpace(v))
 		return nil
 	}
-	ct.Subtype = strings.TrimSpace(v[:pIndex])
+	ct.Subtype = strings.ToLower(strings.TrimSpace(v[:pIndex]))
 	v = v[pIndex+1:]
 	ps := strings.Split(v, ";")
 	if len(ps) == 0 {
@@ -207,12 +343,28 @@ func (ct *ContentType) Parse(v string) error {
 		ct.Parameters = map[string]string{}
 	}
 	for _, p := range ps {
-		kv := strings.Split(p, "=")
-		ct.Parameters[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		ct.Parameters[strings.ToLower(strings.TrimSpace(kv[0]))] = strings.Trim(strings.TrimSpace(kv[1]), `"`)
 	}
 	return nil
 }
 
+// MediaType returns the type and subtype without parameters, e.g. "application/json".
+func (ct ContentType) MediaType() string {
+	if ct.Type == "" && ct.Subtype == "" {
+		return ""
+	}
+	return ct.Type + "/" + ct.Subtype
+}
+
+// IsJSON reports whether the content type is JSON or a JSON based type like ContentTypeHyperItem.
+func (ct ContentType) IsJSON() bool {
+	return ct.Type == "application" && (ct.Subtype == "json" || strings.HasSuffix(ct.Subtype, "+json"))
+}
+
 func (ct ContentType) String() string {
 	var buf bytes.Buffer
 	buf.WriteString(ct.Type)
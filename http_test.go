@@ -0,0 +1,161 @@
+package hyper_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cognicraft/hyper"
+)
+
+func TestReadCommand(t *testing.T) {
+	tests := []struct {
+		name          string
+		contentType   string
+		body          string
+		limit         int64
+		expectStatus  int
+		expectCommand hyper.Command
+	}{
+		{
+			name:          "json",
+			contentType:   hyper.ContentTypeJSON,
+			body:          `{"@action":"rename","name":"foo"}`,
+			expectCommand: hyper.Command{Action: "rename", Arguments: hyper.Arguments{"name": "foo"}},
+		},
+		{
+			name:          "json-charset",
+			contentType:   "Application/JSON; charset=UTF-8",
+			body:          `{"@action":"rename","name":"foo"}`,
+			expectCommand: hyper.Command{Action: "rename", Arguments: hyper.Arguments{"name": "foo"}},
+		},
+		{
+			name:          "hyper-item",
+			contentType:   hyper.ContentTypeHyperItem,
+			body:          `{"name":"foo"}`,
+			expectCommand: hyper.Command{Arguments: hyper.Arguments{"name": "foo"}},
+		},
+		{
+			name:          "url-encoded",
+			contentType:   hyper.ContentTypeURLEncoded,
+			body:          "@action=tag&tag=a&tag=b",
+			expectCommand: hyper.Command{Action: "tag", Arguments: hyper.Arguments{"tag": []string{"a", "b"}}},
+		},
+		{
+			name:          "empty",
+			contentType:   hyper.ContentTypeJSON,
+			expectCommand: hyper.Command{Arguments: hyper.Arguments{}},
+		},
+		{
+			name:         "malformed-json",
+			contentType:  hyper.ContentTypeJSON,
+			body:         `{"name":`,
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			name:         "unsupported-media-type",
+			contentType:  "text/plain",
+			body:         "name=foo",
+			expectStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:         "too-large",
+			contentType:  hyper.ContentTypeJSON,
+			body:         `{"name":"` + strings.Repeat("a", 100) + `"}`,
+			limit:        64,
+			expectStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:         "too-large-url-encoded",
+			contentType:  hyper.ContentTypeURLEncoded,
+			body:         "name=" + strings.Repeat("a", 100),
+			limit:        64,
+			expectStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:          "within-limit",
+			contentType:   hyper.ContentTypeJSON,
+			body:          `{"name":"foo"}`,
+			limit:         64,
+			expectCommand: hyper.Command{Arguments: hyper.Arguments{"name": "foo"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+			r.Header.Set(hyper.HeaderContentType, test.contentType)
+			var c hyper.Command
+			var err error
+			if test.limit > 0 {
+				c, err = hyper.ReadCommandLimit(r, test.limit)
+			} else {
+				c, err = hyper.ReadCommand(r)
+			}
+			if test.expectStatus != 0 {
+				serr, ok := err.(*hyper.StatusError)
+				if !ok {
+					t.Fatalf("want: *hyper.StatusError, got: %#v", err)
+				}
+				if test.expectStatus != serr.Status {
+					t.Errorf("want: %d, got: %d", test.expectStatus, serr.Status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(test.expectCommand, c) {
+				t.Errorf("want: %#v, got: %#v", test.expectCommand, c)
+			}
+		})
+	}
+}
+
+func TestContentTypeParse(t *testing.T) {
+	tests := []struct {
+		in     string
+		expect hyper.ContentType
+	}{
+		{
+			in:     "",
+			expect: hyper.ContentType{},
+		},
+		{
+			in:     "application/json",
+			expect: hyper.ContentType{Type: "application", Subtype: "json"},
+		},
+		{
+			in:     " Application/Vnd.Hyper-Item+JSON ",
+			expect: hyper.ContentType{Type: "application", Subtype: "vnd.hyper-item+json"},
+		},
+		{
+			in: `application/json; Charset="UTF-8"`,
+			expect: hyper.ContentType{
+				Type:       "application",
+				Subtype:    "json",
+				Parameters: map[string]string{"charset": "UTF-8"},
+			},
+		},
+		{
+			in: "multipart/form-data; boundary=xyz; ; flag",
+			expect: hyper.ContentType{
+				Type:       "multipart",
+				Subtype:    "form-data",
+				Parameters: map[string]string{"boundary": "xyz"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			got := hyper.ContentType{}
+			if err := got.Parse(test.in); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(test.expect, got) {
+				t.Errorf("want: %#v, got: %#v", test.expect, got)
+			}
+		})
+	}
+}
@@ -130,3 +130,10 @@ func ItemRelEquals(rel string) func(Item) bool {
 		return rel == i.Rel
 	}
 }
+
+// ItemTypeEquals is used to Filter a collection of Items by type
+func ItemTypeEquals(t string) func(Item) bool {
+	return func(i Item) bool {
+		return t == i.Type
+	}
+}
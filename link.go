@@ -16,6 +16,19 @@ type Link struct {
 	AcceptLanguage string     `json:"accept-language,omitempty"`
 }
 
+// Expand returns the target URI of the Link. A templated Link is expanded with the
+// Arguments, completed with the values of its Parameters.
+func (l Link) Expand(args Arguments) (string, error) {
+	if l.Template == "" {
+		return l.Href, nil
+	}
+	args, err := l.Parameters.Arguments(args)
+	if err != nil {
+		return "", err
+	}
+	return ExpandURITemplate(l.Template, args)
+}
+
 // Links .
 type Links []Link
 
@@ -0,0 +1,63 @@
+package hyper
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ClientOption configures a Client.
+type ClientOption func(*Client)
+
+// Middleware wraps the round trip of every request that is sent by a Client,
+// e.g. to log, trace or measure it. Middleware must not modify the request; use
+// Request.Clone to derive a modified one.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an adapter to allow the use of ordinary functions as http.RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f(r).
+func (f RoundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// WithHTTPClient makes the Client send its requests with hc.
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithTransport makes the Client send its requests with rt.
+func WithTransport(rt http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		hc := *c.httpClient
+		hc.Transport = rt
+		c.httpClient = &hc
+	}
+}
+
+// WithTimeout limits the time a request of the Client may take, including reading the response.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		hc := *c.httpClient
+		hc.Timeout = d
+		c.httpClient = &hc
+	}
+}
+
+// WithMiddleware adds Middleware to the Client. The first Middleware is the outermost one.
+func WithMiddleware(mws ...Middleware) ClientOption {
+	return func(c *Client) {
+		c.middleware = append(c.middleware, mws...)
+	}
+}
+
+// WithBaseURL makes the Client resolve relative request URLs, e.g. "/orders" or the
+// hrefs of Links and Actions, against base.
+func WithBaseURL(base *url.URL) ClientOption {
+	return func(c *Client) {
+		c.baseURL = base
+	}
+}
@@ -1,5 +1,9 @@
 package hyper
 
+import (
+	"fmt"
+)
+
 // Parameter .
 type Parameter struct {
 	Label       string        `json:"label,omitempty"`
@@ -37,6 +41,28 @@ func (as Parameters) FindByName(name string) (Parameter, bool) {
 	return Parameter{}, false
 }
 
+// Arguments returns a copy of args that is completed with the values of the Parameters.
+// It fails if a required parameter is neither supplied nor has a value.
+func (as Parameters) Arguments(args Arguments) (Arguments, error) {
+	res := Arguments{}
+	for _, p := range as {
+		if _, ok := args[p.Name]; ok {
+			continue
+		}
+		if p.Value != nil {
+			res[p.Name] = p.Value
+			continue
+		}
+		if p.Required {
+			return nil, fmt.Errorf("missing required parameter %q", p.Name)
+		}
+	}
+	for k, v := range args {
+		res[k] = v
+	}
+	return res, nil
+}
+
 // SelectOption .
 type SelectOption struct {
 	Label       string         `json:"label,omitempty"`
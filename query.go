@@ -1,45 +1,710 @@
 package hyper
 
 import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"unicode"
 )
 
+// Query evaluates the query q against the root Item (see EvalQuery). Invalid queries and
+// queries without a result yield nil.
 func Query(root Item, q string) interface{} {
-	switch q {
-	case "":
+	res, err := EvalQuery(root, q)
+	if err != nil {
 		return nil
-	case ".":
-		return root
-	case ".label":
-		return root.Label
-	case ".description":
-		return root.Description
-	case ".render":
-		return root.Render
-	case ".id":
-		return root.ID
-	case ".rel":
-		return root.Rel
-	case ".type":
-		return root.Type
-	case ".properties":
-		return root.Properties
-	case ".data":
-		return root.Data
-	case ".links":
-		return root.Links
-	case ".actions":
-		return root.Actions
-	case ".items":
-		return root.Items
-	case ".errors":
-		return root.Errors
+	}
+	return res
+}
+
+// EvalQuery evaluates the query q against the root Item.
+//
+// A query is a path that starts at the root Item (".") or at the Item with a specific id
+// ("#id", see Search) and navigates with the following steps:
+//
+//	.name         the member with the JSON name, e.g. .label or .items
+//	.*            all members or elements
+//	..name        the member with the name at any depth, e.g. ..items
+//	..*           all members and elements at any depth
+//	[n]           the element at index n; negative indexes count from the end
+//	[start:end]   a slice of the elements; start and end are optional
+//	[*]           all elements
+//	[key=value]   the first element whose member key equals value, e.g. [rel=next]
+//	["name"]      the member with a name that contains special characters
+//	[?(expr)]     all elements for which the predicate expr holds
+//
+// Properties can also be navigated by name: .properties.total is the value of the
+// Property named "total". The id after "#" extends to the end of the query unless it
+// is quoted, e.g. #"order:1".properties.
+//
+// Queries that contain *, .., slices or predicates yield a []interface{} of all matches;
+// all other queries yield the single match or nil.
+//
+// Predicates combine comparisons (==, !=, <, <=, >, >=) with &&, || and ! and parentheses.
+// Paths in a predicate are relative to the element, literals are strings, numbers, true,
+// false and null. Numbers compare numerically and strings lexically:
+//
+//	.items[?(.type=="order" && .properties.total > 100)]
+//
+// A projection {key: expr, ...} builds a map[string]interface{} from expressions that are
+// evaluated relative to the current node. The pipe q | expr evaluates expr for each match
+// of q, or for the single match:
+//
+//	.items[*] | {id: .id, total: .properties[name=total].value}
+//
+// Use CompileQuery to evaluate a query repeatedly.
+func EvalQuery(root Item, q string) (interface{}, error) {
+	cq, err := CompileQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	return cq.Eval(root), nil
+}
+
+// QueryError describes a syntax error in a query.
+type QueryError struct {
+	Query  string
+	Offset int
+	Msg    string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("query %q: %s at offset %d", e.Query, e.Msg, e.Offset)
+}
+
+type queryPath struct {
+	searchID string
+	search   bool
+	steps    []queryStep
+	plural   bool
+}
+
+func (qp *queryPath) eval(root interface{}) interface{} {
+	nodes := qp.evalNodes(root)
+	if qp.plural {
+		if nodes == nil {
+			return []interface{}{}
+		}
+		return nodes
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+	return nodes[0]
+}
+
+func (qp *queryPath) evalNodes(root interface{}) []interface{} {
+	start := root
+	if qp.search {
+		i, ok := root.(Item)
+		if !ok {
+			return nil
+		}
+		found, ok := Search(i, qp.searchID)
+		if !ok {
+			return nil
+		}
+		start = found
+	}
+	nodes := []interface{}{start}
+	for _, s := range qp.steps {
+		nodes = s.apply(nodes)
+		if len(nodes) == 0 {
+			return nil
+		}
+	}
+	return nodes
+}
+
+type queryStep interface {
+	apply(nodes []interface{}) []interface{}
+}
+
+type fieldStep struct {
+	name string
+}
+
+func (s fieldStep) apply(nodes []interface{}) []interface{} {
+	var res []interface{}
+	for _, n := range nodes {
+		if v, ok := queryMember(n, s.name); ok {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+type wildcardStep struct{}
+
+func (s wildcardStep) apply(nodes []interface{}) []interface{} {
+	var res []interface{}
+	for _, n := range nodes {
+		res = append(res, queryChildren(n)...)
+	}
+	return res
+}
+
+type descendantStep struct {
+	name string
+}
+
+func (s descendantStep) apply(nodes []interface{}) []interface{} {
+	var res []interface{}
+	var visit func(n interface{})
+	visit = func(n interface{}) {
+		if s.name == "" {
+			for _, c := range queryChildren(n) {
+				res = append(res, c)
+				visit(c)
+			}
+			return
+		}
+		if v, ok := queryMember(n, s.name); ok {
+			res = append(res, v)
+		}
+		for _, c := range queryChildren(n) {
+			visit(c)
+		}
+	}
+	for _, n := range nodes {
+		visit(n)
+	}
+	return res
+}
+
+type indexStep struct {
+	index int
+}
+
+func (s indexStep) apply(nodes []interface{}) []interface{} {
+	var res []interface{}
+	for _, n := range nodes {
+		v := queryIndirect(reflect.ValueOf(n))
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			continue
+		}
+		i := s.index
+		if i < 0 {
+			i += v.Len()
+		}
+		if i < 0 || i >= v.Len() {
+			continue
+		}
+		res = append(res, v.Index(i).Interface())
+	}
+	return res
+}
+
+type sliceStep struct {
+	start, end       int
+	hasStart, hasEnd bool
+}
+
+func (s sliceStep) apply(nodes []interface{}) []interface{} {
+	var res []interface{}
+	for _, n := range nodes {
+		v := queryIndirect(reflect.ValueOf(n))
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			continue
+		}
+		start, end := 0, v.Len()
+		if s.hasStart {
+			start = clampIndex(s.start, v.Len())
+		}
+		if s.hasEnd {
+			end = clampIndex(s.end, v.Len())
+		}
+		for i := start; i < end; i++ {
+			res = append(res, v.Index(i).Interface())
+		}
+	}
+	return res
+}
+
+func clampIndex(i int, l int) int {
+	if i < 0 {
+		i += l
+	}
+	if i < 0 {
+		return 0
+	}
+	if i > l {
+		return l
+	}
+	return i
+}
+
+type selectStep struct {
+	key   string
+	value string
+}
+
+func (s selectStep) apply(nodes []interface{}) []interface{} {
+	var res []interface{}
+	for _, n := range nodes {
+		v := queryIndirect(reflect.ValueOf(n))
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			continue
+		}
+		for i := 0; i < v.Len(); i++ {
+			e := v.Index(i).Interface()
+			if m, ok := queryMember(e, s.key); ok && m != nil && formValue(m) == s.value {
+				res = append(res, e)
+				break
+			}
+		}
+	}
+	return res
+}
+
+// queryIndirect dereferences pointers and interfaces.
+func queryIndirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// queryMember returns the member of n with the given JSON name.
+func queryMember(n interface{}, name string) (interface{}, bool) {
+	if ps, ok := n.(Properties); ok {
+		if p, ok := ps.Find(name); ok {
+			return p.Value, true
+		}
+		return nil, false
+	}
+	v := queryIndirect(reflect.ValueOf(n))
+	switch v.Kind() {
+	case reflect.Struct:
+		i, ok := jsonFields(v.Type())[name]
+		if !ok {
+			return nil, false
+		}
+		return queryValue(v.Field(i)), true
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		m := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		if !m.IsValid() {
+			return nil, false
+		}
+		return queryValue(m), true
 	default:
-		if strings.HasPrefix(q, "#") {
-			if item, ok := Search(root, q[1:]); ok {
-				return item
+		return nil, false
+	}
+}
+
+// queryChildren returns the non-empty members of a struct, the values of a map or the
+// elements of a slice.
+func queryChildren(n interface{}) []interface{} {
+	var res []interface{}
+	v := queryIndirect(reflect.ValueOf(n))
+	switch v.Kind() {
+	case reflect.Struct:
+		for _, i := range jsonFieldOrder(v.Type()) {
+			f := v.Field(i)
+			if f.IsZero() {
+				continue
 			}
+			res = append(res, queryValue(f))
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sortValues(keys)
+		for _, k := range keys {
+			res = append(res, queryValue(v.MapIndex(k)))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			res = append(res, queryValue(v.Index(i)))
+		}
+	}
+	return res
+}
+
+// queryValue returns the value, unwrapping interfaces so that e.g. Data yields its content.
+func queryValue(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+func sortValues(vs []reflect.Value) {
+	sort.Slice(vs, func(i, j int) bool {
+		return fmt.Sprint(vs[i].Interface()) < fmt.Sprint(vs[j].Interface())
+	})
+}
+
+var jsonFieldCache sync.Map
+
+type jsonFieldInfo struct {
+	index map[string]int
+	order []int
+}
+
+func jsonFieldInfoOf(t reflect.Type) jsonFieldInfo {
+	if info, ok := jsonFieldCache.Load(t); ok {
+		return info.(jsonFieldInfo)
+	}
+	info := jsonFieldInfo{index: map[string]int{}}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+		info.index[name] = i
+		info.order = append(info.order, i)
+	}
+	jsonFieldCache.Store(t, info)
+	return info
+}
+
+func jsonFields(t reflect.Type) map[string]int {
+	return jsonFieldInfoOf(t).index
+}
+
+func jsonFieldOrder(t reflect.Type) []int {
+	return jsonFieldInfoOf(t).order
+}
+
+type queryParser struct {
+	src string
+	pos int
+}
+
+func (p *queryParser) errorf(format string, args ...interface{}) error {
+	return &QueryError{Query: p.src, Offset: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *queryParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *queryParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *queryParser) peekAt(offset int) byte {
+	if p.pos+offset >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos+offset]
+}
+
+func (p *queryParser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *queryParser) consume(s string) bool {
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) parse() (queryExpr, error) {
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf("empty query")
+	}
+	expr, err := p.parsePipeline()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.eof() {
+		return nil, p.errorf("unexpected %q", p.peek())
+	}
+	return expr, nil
+}
+
+func (p *queryParser) parsePipeline() (queryExpr, error) {
+	left, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if p.peek() != '|' || p.peekAt(1) == '|' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		left = &pipeExpr{left: left, right: right}
+	}
+}
+
+func (p *queryParser) parsePath() (*queryPath, error) {
+	path := &queryPath{}
+	switch p.peek() {
+	case '#':
+		p.pos++
+		path.search = true
+		if p.peek() == '"' || p.peek() == '\'' {
+			id, err := p.parseString()
+			if err != nil {
+				return nil, err
+			}
+			path.searchID = id
+		} else {
+			start := p.pos
+			for !p.eof() && !strings.ContainsRune(" \t\n[|,})", rune(p.peek())) {
+				p.pos++
+			}
+			path.searchID = p.src[start:p.pos]
+		}
+		if path.searchID == "" {
+			return nil, p.errorf("missing id")
+		}
+	case '.':
+		if p.peekAt(1) != '.' && !isQueryNameStart(p.peekAt(1)) && p.peekAt(1) != '*' && p.peekAt(1) != '"' {
+			// the root itself
+			p.pos++
+		}
+	default:
+		return nil, p.errorf("query must start with '.' or '#'")
+	}
+	for !p.eof() {
+		switch p.peek() {
+		case '.':
+			step, plural, err := p.parseDotStep()
+			if err != nil {
+				return nil, err
+			}
+			path.steps = append(path.steps, step)
+			path.plural = path.plural || plural
+		case '[':
+			step, plural, err := p.parseBracketStep()
+			if err != nil {
+				return nil, err
+			}
+			path.steps = append(path.steps, step)
+			path.plural = path.plural || plural
+		default:
+			return path, nil
 		}
-		return nil
 	}
+	return path, nil
+}
+
+func (p *queryParser) parseDotStep() (queryStep, bool, error) {
+	p.pos++
+	descendant := false
+	if p.peek() == '.' {
+		p.pos++
+		descendant = true
+	}
+	var name string
+	switch {
+	case p.peek() == '*':
+		p.pos++
+	case p.peek() == '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, false, err
+		}
+		name = s
+	case isQueryNameStart(p.peek()):
+		name = p.parseName()
+	default:
+		return nil, false, p.errorf("expected a name")
+	}
+	switch {
+	case descendant:
+		return descendantStep{name: name}, true, nil
+	case name == "":
+		return wildcardStep{}, true, nil
+	default:
+		return fieldStep{name: name}, false, nil
+	}
+}
+
+func (p *queryParser) parseBracketStep() (queryStep, bool, error) {
+	p.pos++
+	p.skipSpace()
+	var step queryStep
+	plural := false
+	switch c := p.peek(); {
+	case c == '*':
+		p.pos++
+		step, plural = wildcardStep{}, true
+	case c == '?':
+		p.pos++
+		cond, err := p.parseExpr()
+		if err != nil {
+			return nil, false, err
+		}
+		step, plural = filterStep{cond: cond}, true
+	case c == '-' || c == ':' || isDigit(c):
+		s, isSlice, err := p.parseIndexOrSlice()
+		if err != nil {
+			return nil, false, err
+		}
+		step, plural = s, isSlice
+	case c == '"' || c == '\'' || isQueryNameStart(c):
+		var key string
+		var err error
+		quoted := c == '"' || c == '\''
+		if quoted {
+			key, err = p.parseString()
+			if err != nil {
+				return nil, false, err
+			}
+		} else {
+			key = p.parseName()
+		}
+		p.skipSpace()
+		if p.peek() == '=' {
+			p.pos++
+			p.skipSpace()
+			value, err := p.parseSelectValue()
+			if err != nil {
+				return nil, false, err
+			}
+			step = selectStep{key: key, value: value}
+		} else if quoted {
+			step = fieldStep{name: key}
+		} else {
+			return nil, false, p.errorf("expected '='")
+		}
+	default:
+		return nil, false, p.errorf("invalid selector")
+	}
+	p.skipSpace()
+	if p.peek() != ']' {
+		return nil, false, p.errorf("expected ']'")
+	}
+	p.pos++
+	return step, plural, nil
+}
+
+func (p *queryParser) parseIndexOrSlice() (queryStep, bool, error) {
+	start, hasStart, err := p.parseOptionalInt()
+	if err != nil {
+		return nil, false, err
+	}
+	p.skipSpace()
+	if p.peek() != ':' {
+		if !hasStart {
+			return nil, false, p.errorf("expected an index")
+		}
+		return indexStep{index: start}, false, nil
+	}
+	p.pos++
+	p.skipSpace()
+	end, hasEnd, err := p.parseOptionalInt()
+	if err != nil {
+		return nil, false, err
+	}
+	return sliceStep{start: start, hasStart: hasStart, end: end, hasEnd: hasEnd}, true, nil
+}
+
+func (p *queryParser) parseOptionalInt() (int, bool, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	for isDigit(p.peek()) {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, false, nil
+	}
+	i, err := strconv.Atoi(p.src[start:p.pos])
+	if err != nil {
+		return 0, false, p.errorf("invalid index %q", p.src[start:p.pos])
+	}
+	return i, true, nil
+}
+
+func (p *queryParser) parseSelectValue() (string, error) {
+	if p.peek() == '"' || p.peek() == '\'' {
+		return p.parseString()
+	}
+	start := p.pos
+	for !p.eof() && p.peek() != ']' {
+		p.pos++
+	}
+	value := strings.TrimSpace(p.src[start:p.pos])
+	if value == "" {
+		return "", p.errorf("expected a value")
+	}
+	return value, nil
+}
+
+func (p *queryParser) parseString() (string, error) {
+	quote := p.peek()
+	start := p.pos
+	p.pos++
+	var buf strings.Builder
+	for {
+		if p.eof() {
+			p.pos = start
+			return "", p.errorf("unterminated string")
+		}
+		c := p.peek()
+		p.pos++
+		switch c {
+		case quote:
+			return buf.String(), nil
+		case '\\':
+			if p.eof() {
+				p.pos = start
+				return "", p.errorf("unterminated string")
+			}
+			buf.WriteByte(p.peek())
+			p.pos++
+		default:
+			buf.WriteByte(c)
+		}
+	}
+}
+
+func (p *queryParser) parseName() string {
+	start := p.pos
+	for !p.eof() && isQueryNameChar(p.peek()) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func isQueryNameStart(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || c == '@'
+}
+
+func isQueryNameChar(c byte) bool {
+	return isQueryNameStart(c) || isDigit(c) || c == '-'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
 }
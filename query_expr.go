@@ -0,0 +1,433 @@
+package hyper
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// CompiledQuery is a compiled query that can be evaluated against many Items. It is safe for
+// concurrent use.
+type CompiledQuery struct {
+	src  string
+	expr queryExpr
+}
+
+// CompileQuery parses a query (see EvalQuery) and returns a CompiledQuery that can be evaluated
+// repeatedly. Syntax errors are reported as *QueryError.
+func CompileQuery(q string) (*CompiledQuery, error) {
+	p := &queryParser{src: q}
+	expr, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return &CompiledQuery{src: q, expr: expr}, nil
+}
+
+// MustCompileQuery is like CompileQuery but panics if the query cannot be parsed.
+func MustCompileQuery(q string) *CompiledQuery {
+	cq, err := CompileQuery(q)
+	if err != nil {
+		panic(err)
+	}
+	return cq
+}
+
+// Eval evaluates the query against the root Item.
+func (cq *CompiledQuery) Eval(root Item) interface{} {
+	return cq.expr.eval(root)
+}
+
+// String returns the source of the query.
+func (cq *CompiledQuery) String() string {
+	return cq.src
+}
+
+// queryExpr is a node of a compiled query that is evaluated relative to a node.
+type queryExpr interface {
+	eval(n interface{}) interface{}
+}
+
+// queryPlural reports whether an expression yields a []interface{} of matches.
+func queryPlural(e queryExpr) bool {
+	switch e := e.(type) {
+	case *queryPath:
+		return e.plural
+	case *pipeExpr:
+		return queryPlural(e.left)
+	default:
+		return false
+	}
+}
+
+// pipeExpr evaluates right for each result of left.
+type pipeExpr struct {
+	left, right queryExpr
+}
+
+func (e *pipeExpr) eval(n interface{}) interface{} {
+	l := e.left.eval(n)
+	if !queryPlural(e.left) {
+		if l == nil {
+			return nil
+		}
+		return e.right.eval(l)
+	}
+	res := []interface{}{}
+	for _, v := range l.([]interface{}) {
+		res = append(res, e.right.eval(v))
+	}
+	return res
+}
+
+// projectExpr builds an object with the given keys.
+type projectExpr struct {
+	keys   []string
+	values []queryExpr
+}
+
+func (e *projectExpr) eval(n interface{}) interface{} {
+	res := make(map[string]interface{}, len(e.keys))
+	for i, k := range e.keys {
+		res[k] = e.values[i].eval(n)
+	}
+	return res
+}
+
+type literalExpr struct {
+	value interface{}
+}
+
+func (e literalExpr) eval(n interface{}) interface{} {
+	return e.value
+}
+
+type notExpr struct {
+	x queryExpr
+}
+
+func (e notExpr) eval(n interface{}) interface{} {
+	return !queryTruthy(e.x.eval(n))
+}
+
+type logicalExpr struct {
+	op          string
+	left, right queryExpr
+}
+
+func (e logicalExpr) eval(n interface{}) interface{} {
+	l := queryTruthy(e.left.eval(n))
+	if e.op == "&&" {
+		return l && queryTruthy(e.right.eval(n))
+	}
+	return l || queryTruthy(e.right.eval(n))
+}
+
+type compareExpr struct {
+	op          string
+	left, right queryExpr
+}
+
+func (e compareExpr) eval(n interface{}) interface{} {
+	return queryCompare(e.op, e.left.eval(n), e.right.eval(n))
+}
+
+// filterStep selects the elements for which the predicate holds.
+type filterStep struct {
+	cond queryExpr
+}
+
+func (s filterStep) apply(nodes []interface{}) []interface{} {
+	var res []interface{}
+	for _, n := range nodes {
+		v := queryIndirect(reflect.ValueOf(n))
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			continue
+		}
+		for i := 0; i < v.Len(); i++ {
+			e := queryValue(v.Index(i))
+			if queryTruthy(s.cond.eval(e)) {
+				res = append(res, e)
+			}
+		}
+	}
+	return res
+}
+
+// queryTruthy reports whether a value counts as true in a predicate: false, nil, zero numbers,
+// empty strings and empty lists do not.
+func queryTruthy(x interface{}) bool {
+	v := queryIndirect(reflect.ValueOf(x))
+	if !v.IsValid() {
+		return false
+	}
+	if f, ok := queryNumber(v); ok {
+		return f != 0
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len() > 0
+	default:
+		return true
+	}
+}
+
+func queryNumber(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// queryCompare compares numbers numerically and strings lexically, regardless of their
+// concrete types. Values of different kinds are only equal if they are deeply equal.
+func queryCompare(op string, a, b interface{}) bool {
+	av, bv := queryIndirect(reflect.ValueOf(a)), queryIndirect(reflect.ValueOf(b))
+	var c int
+	switch {
+	case !av.IsValid() || !bv.IsValid():
+		if op != "==" && op != "!=" {
+			return false
+		}
+		return (av.IsValid() == bv.IsValid()) == (op == "==")
+	case isQueryNumber(av) && isQueryNumber(bv):
+		x, _ := queryNumber(av)
+		y, _ := queryNumber(bv)
+		c = compareFloats(x, y)
+	case av.Kind() == reflect.String && bv.Kind() == reflect.String:
+		c = compareStrings(av.String(), bv.String())
+	case av.Kind() == reflect.Bool && bv.Kind() == reflect.Bool:
+		if av.Bool() != bv.Bool() {
+			c = 1
+		}
+		if op != "==" && op != "!=" {
+			return false
+		}
+	default:
+		if op != "==" && op != "!=" {
+			return false
+		}
+		if !reflect.DeepEqual(av.Interface(), bv.Interface()) {
+			c = 1
+		}
+	}
+	switch op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+func isQueryNumber(v reflect.Value) bool {
+	_, ok := queryNumber(v)
+	return ok
+}
+
+func compareFloats(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareStrings(x, y string) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// parseExpr parses an expression of the form a || b, a && b, !a or a op b with op being one
+// of ==, !=, <, <=, > or >=.
+func (p *queryParser) parseExpr() (queryExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if !p.consume("||") {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpr{op: "||", left: left, right: right}
+	}
+}
+
+func (p *queryParser) parseAnd() (queryExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if !p.consume("&&") {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpr{op: "&&", left: left, right: right}
+	}
+}
+
+var queryCompareOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+func (p *queryParser) parseUnary() (queryExpr, error) {
+	p.skipSpace()
+	if p.peek() == '!' && p.peekAt(1) != '=' {
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{x: x}, nil
+	}
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	for _, op := range queryCompareOps {
+		if p.consume(op) {
+			right, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			return compareExpr{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseOperand() (queryExpr, error) {
+	p.skipSpace()
+	switch c := p.peek(); {
+	case c == '.' || c == '#':
+		return p.parsePath()
+	case c == '{':
+		return p.parseProjection()
+	case c == '(':
+		p.pos++
+		x, err := p.parsePipeline()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !p.consume(")") {
+			return nil, p.errorf("expected ')'")
+		}
+		return x, nil
+	case c == '"' || c == '\'':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return literalExpr{value: s}, nil
+	case c == '-' || isDigit(c):
+		return p.parseNumber()
+	case isQueryNameStart(c):
+		start := p.pos
+		switch name := p.parseName(); name {
+		case "true":
+			return literalExpr{value: true}, nil
+		case "false":
+			return literalExpr{value: false}, nil
+		case "null":
+			return literalExpr{value: nil}, nil
+		default:
+			p.pos = start
+			return nil, p.errorf("unknown identifier %q", name)
+		}
+	case p.eof():
+		return nil, p.errorf("unexpected end of query")
+	default:
+		return nil, p.errorf("query must start with '.' or '#'")
+	}
+}
+
+func (p *queryParser) parseNumber() (queryExpr, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	for isDigit(p.peek()) || p.peek() == '.' || p.peek() == 'e' || p.peek() == 'E' ||
+		(p.peek() == '-' || p.peek() == '+') && (p.src[p.pos-1] == 'e' || p.src[p.pos-1] == 'E') {
+		p.pos++
+	}
+	f, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+	if err != nil {
+		p.pos = start
+		return nil, p.errorf("invalid number")
+	}
+	return literalExpr{value: f}, nil
+}
+
+// parseProjection parses {key: expr, ...}. Keys are names or quoted strings.
+func (p *queryParser) parseProjection() (queryExpr, error) {
+	p.pos++
+	e := &projectExpr{}
+	for {
+		p.skipSpace()
+		if p.consume("}") {
+			return e, nil
+		}
+		if len(e.keys) > 0 {
+			if !p.consume(",") {
+				return nil, p.errorf("expected ',' or '}'")
+			}
+			p.skipSpace()
+		}
+		var key string
+		switch c := p.peek(); {
+		case c == '"' || c == '\'':
+			s, err := p.parseString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		case isQueryNameStart(c):
+			key = p.parseName()
+		default:
+			return nil, p.errorf("expected a key")
+		}
+		p.skipSpace()
+		if !p.consume(":") {
+			return nil, p.errorf("expected ':'")
+		}
+		value, err := p.parsePipeline()
+		if err != nil {
+			return nil, err
+		}
+		e.keys = append(e.keys, key)
+		e.values = append(e.values, value)
+	}
+}
@@ -165,3 +165,140 @@ func TestQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestEvalQuery(t *testing.T) {
+	root := hyper.Item{
+		ID: "orders",
+		Properties: hyper.Properties{
+			{Name: "count", Value: 3},
+			{Name: "accept-language", Value: "de"},
+		},
+		Links: hyper.Links{
+			{Rel: "self", Href: "/orders"},
+			{Rel: "next", Href: "/orders?page=2"},
+		},
+		Items: hyper.Items{
+			{
+				ID:         "order:1",
+				Type:       "order",
+				Properties: hyper.Properties{{Name: "total", Value: 50}},
+				Items:      hyper.Items{{ID: "order:1:line:1"}},
+			},
+			{
+				ID:         "order:2",
+				Type:       "order",
+				Properties: hyper.Properties{{Name: "total", Value: 150}},
+			},
+			{
+				ID:   "order:3",
+				Type: "draft",
+			},
+		},
+	}
+
+	tests := []struct {
+		q      string
+		result interface{}
+	}{
+		{q: ".items[0].properties", result: hyper.Properties{{Name: "total", Value: 50}}},
+		{q: ".items[-1].id", result: "order:3"},
+		{q: ".items[5].id", result: nil},
+		{q: ".properties[name=count].value", result: 3},
+		{q: ".properties.count", result: 3},
+		{q: `.properties["accept-language"]`, result: "de"},
+		{q: ".links[rel=next].href", result: "/orders?page=2"},
+		{q: `.links[rel="self"].href`, result: "/orders"},
+		{q: ".links[rel=prev].href", result: nil},
+		{q: ".items[*].id", result: []interface{}{"order:1", "order:2", "order:3"}},
+		{q: ".items.*.type", result: []interface{}{"order", "order", "draft"}},
+		{q: ".items[1:].id", result: []interface{}{"order:2", "order:3"}},
+		{q: ".items[:-2].id", result: []interface{}{"order:1"}},
+		{q: "..id", result: []interface{}{"orders", "order:1", "order:1:line:1", "order:2", "order:3"}},
+		{q: "..total", result: []interface{}{50, 150}},
+		{q: ".links[*].rel", result: []interface{}{"self", "next"}},
+		{q: ".items[*].foo", result: []interface{}{}},
+		{q: `#"order:1".items[0].id`, result: "order:1:line:1"},
+		{q: "#order:2", result: root.Items[1]},
+		{q: `.items[?(.type=="order")].id`, result: []interface{}{"order:1", "order:2"}},
+		{q: `.items[?(.type=="order" && .properties.total > 100)].id`, result: []interface{}{"order:2"}},
+		{q: `.items[?(.properties.total >= 50 || .type == 'draft')].id`, result: []interface{}{"order:1", "order:2", "order:3"}},
+		{q: `.items[?(!(.type == "order"))].id`, result: []interface{}{"order:3"}},
+		{q: `.items[?(.properties.total != null)].id`, result: []interface{}{"order:1", "order:2"}},
+		{q: `.items[?(.items)].id`, result: []interface{}{"order:1"}},
+		{q: `.items[?(.properties.total < -1)].id`, result: []interface{}{}},
+		{q: `.links[?(.rel > "r")] | .href`, result: []interface{}{"/orders"}},
+		{q: `{count: .properties.count, next: .links[rel=next].href}`, result: map[string]interface{}{"count": 3, "next": "/orders?page=2"}},
+		{q: `.items[?(.type=="order")] | {id: .id, total: .properties[name=total].value, big: .properties.total > 100}`, result: []interface{}{
+			map[string]interface{}{"id": "order:1", "total": 50, "big": false},
+			map[string]interface{}{"id": "order:2", "total": 150, "big": true},
+		}},
+		{q: `#order:2 | {"the id": .id}`, result: map[string]interface{}{"the id": "order:2"}},
+		{q: `.properties.count == 3`, result: true},
+	}
+	for _, test := range tests {
+		t.Run(test.q, func(t *testing.T) {
+			got, err := hyper.EvalQuery(root, test.q)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(test.result, got) {
+				t.Errorf("want: %s, got: %s", hyper.JSONString(test.result), hyper.JSONString(got))
+			}
+		})
+	}
+}
+
+func TestEvalQueryError(t *testing.T) {
+	tests := []string{
+		"",
+		"foo",
+		".items[",
+		".items[0",
+		".items[x]",
+		".items[rel=]",
+		`.properties["foo]`,
+		".items.",
+		"#",
+		".label extra",
+		".items[?(.type ==)]",
+		".items[?(.type == \"order\"]",
+		".items[?(foo)]",
+		"{id .id}",
+		"{id: .id",
+		".items | ",
+		".label = 1",
+	}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			if _, err := hyper.EvalQuery(hyper.Item{}, test); err == nil {
+				t.Errorf("want: error, got: nil")
+			}
+		})
+	}
+}
+
+func TestCompileQuery(t *testing.T) {
+	q := hyper.MustCompileQuery(`.items[?(.properties.total > 100)] | .id`)
+	tests := []struct {
+		root   hyper.Item
+		result interface{}
+	}{
+		{root: hyper.Item{}, result: []interface{}{}},
+		{
+			root: hyper.Item{Items: hyper.Items{
+				{ID: "a", Properties: hyper.Properties{{Name: "total", Value: 101.5}}},
+				{ID: "b", Properties: hyper.Properties{{Name: "total", Value: uint(100)}}},
+			}},
+			result: []interface{}{"a"},
+		},
+	}
+	for _, test := range tests {
+		got := q.Eval(test.root)
+		if !reflect.DeepEqual(test.result, got) {
+			t.Errorf("want: %s, got: %s", hyper.JSONString(test.result), hyper.JSONString(got))
+		}
+	}
+	if _, err := hyper.CompileQuery(".items[?("); err == nil {
+		t.Errorf("want: error, got: nil")
+	}
+}
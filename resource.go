@@ -0,0 +1,194 @@
+package hyper
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Resource is an http.Handler that serves a hypermedia resource. It is declared by the
+// representation that is returned for GET requests and the Actions that can be performed on
+// the resource, each with the CommandHandler that executes it:
+//
+//	order := hyper.NewResource(getOrder).
+//		Action(cancelAction, isOpen, cancelOrder).
+//		Action(payAction, isUnpaid, payOrder)
+//
+// The Resource adds a self link and the Actions that are available for the current state and
+// caller to the representation. The Href and Method of an Action default to the resource and
+// POST, the NameAction parameter is added automatically. Commands are read with ReadCommand,
+// checked for availability of their action and validated with Validate before the handler is
+// called. Responses are written as hyper-items with Write, WriteConditional and WriteError.
+type Resource struct {
+	get     func(r *http.Request) (Item, error)
+	actions []resourceAction
+}
+
+type resourceAction struct {
+	action    Action
+	available func(r *http.Request, i Item) bool
+	handler   CommandHandler
+}
+
+// NewResource creates a Resource whose representation is built by get.
+func NewResource(get func(r *http.Request) (Item, error)) *Resource {
+	return &Resource{
+		get: get,
+	}
+}
+
+// Action registers an Action of the Resource, identified by its Rel, together with the
+// CommandHandler that executes it. If available is not nil, the Action is only offered and
+// accepted if available returns true for the request and the current representation. If the
+// handler returns the zero Item, the current representation is written instead, e.g. to show
+// the new state of the resource after it has been changed.
+func (res *Resource) Action(a Action, available func(r *http.Request, i Item) bool, h CommandHandler, mws ...CommandMiddleware) *Resource {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	res.actions = append(res.actions, resourceAction{
+		action:    a,
+		available: available,
+		handler:   h,
+	})
+	return res
+}
+
+func (res *Resource) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !acceptsHyperItem(r.Header.Get(HeaderAccept)) {
+		WriteError(w, http.StatusNotAcceptable, fmt.Errorf("not acceptable: %s", r.Header.Get(HeaderAccept)))
+		return
+	}
+	i, available, err := res.represent(r)
+	if err != nil {
+		writeCommandError(w, err)
+		return
+	}
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		WriteConditional(w, r, http.StatusOK, i, Validators{})
+		return
+	}
+
+	c, err := ReadCommand(r)
+	if err != nil {
+		WriteError(w, ErrorStatus(err, http.StatusBadRequest), err)
+		return
+	}
+	ra, ok := res.find(c.Action)
+	if !ok {
+		rels := make([]string, 0, len(available))
+		for _, a := range available {
+			rels = append(rels, a.Rel)
+		}
+		Write(w, http.StatusBadRequest, Item{
+			Errors: Errors{
+				{
+					Message:     fmt.Sprintf("unknown action %q", c.Action),
+					Description: "valid actions: " + strings.Join(rels, ", "),
+					Code:        CodeUnknownAction,
+				},
+			},
+		})
+		return
+	}
+	a, ok := available.FindByRel(ra.action.Rel)
+	if !ok {
+		WriteError(w, http.StatusConflict, fmt.Errorf("action %q is not available", c.Action))
+		return
+	}
+	if r.Method != a.Method {
+		w.Header().Set(HeaderAllow, strings.Join(res.methods(available), ", "))
+		WriteError(w, http.StatusMethodNotAllowed, fmt.Errorf("action %q requires method %s", c.Action, a.Method))
+		return
+	}
+	if errs := Validate(a, c); len(errs) > 0 {
+		Write(w, http.StatusBadRequest, Item{Errors: errs})
+		return
+	}
+	result, err := ra.handler(r, c)
+	if err != nil {
+		writeCommandError(w, err)
+		return
+	}
+	if reflect.DeepEqual(result, Item{}) {
+		result, _, err = res.represent(r)
+		if err != nil {
+			writeCommandError(w, err)
+			return
+		}
+	}
+	Write(w, http.StatusOK, result)
+}
+
+// represent builds the representation with a self link and the available Actions.
+func (res *Resource) represent(r *http.Request) (Item, Actions, error) {
+	i, err := res.get(r)
+	if err != nil {
+		return Item{}, nil, err
+	}
+	self, ok := i.Links.FindByRel(RelSelf)
+	if !ok {
+		self = Link{Rel: RelSelf, Href: r.URL.RequestURI()}
+		i.AddLink(self)
+	}
+	available := Actions{}
+	for _, ra := range res.actions {
+		if ra.available != nil && !ra.available(r, i) {
+			continue
+		}
+		a := ra.action
+		if a.Href == "" && a.Template == "" {
+			a.Href = self.Href
+		}
+		if a.Method == "" {
+			a.Method = MethodPOST
+		}
+		if _, ok := a.Parameters.FindByName(NameAction); !ok {
+			a.Parameters = append(Parameters{ActionParameter(a.Rel)}, a.Parameters...)
+		}
+		available = append(available, a)
+	}
+	i.AddActions(available)
+	return i, available, nil
+}
+
+func (res *Resource) find(action string) (resourceAction, bool) {
+	for _, ra := range res.actions {
+		if ra.action.Rel == action {
+			return ra, true
+		}
+	}
+	return resourceAction{}, false
+}
+
+func (res *Resource) methods(available Actions) []string {
+	methods := []string{http.MethodGet, http.MethodHead}
+	seen := map[string]bool{}
+	for _, a := range available {
+		if !seen[a.Method] {
+			seen[a.Method] = true
+			methods = append(methods, a.Method)
+		}
+	}
+	return methods
+}
+
+// acceptsHyperItem reports whether an Accept header admits hyper-items or JSON.
+func acceptsHyperItem(accept string) bool {
+	if strings.TrimSpace(accept) == "" {
+		return true
+	}
+	for _, mr := range strings.Split(accept, ",") {
+		ct := ContentType{}
+		ct.Parse(mr)
+		if q, ok := ct.Parameters["q"]; ok && strings.Trim(q, "0.") == "" {
+			continue
+		}
+		switch ct.MediaType() {
+		case "*/*", "application/*", ContentTypeHyperItem, ContentTypeJSON:
+			return true
+		}
+	}
+	return false
+}
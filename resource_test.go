@@ -0,0 +1,180 @@
+package hyper_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cognicraft/hyper"
+)
+
+func TestResource(t *testing.T) {
+	state := ""
+	isOpen := func(r *http.Request, i hyper.Item) bool {
+		return state == "open"
+	}
+	res := hyper.NewResource(func(r *http.Request) (hyper.Item, error) {
+		return hyper.Item{Label: state}, nil
+	}).Action(
+		hyper.Action{
+			Rel:        "cancel",
+			Parameters: hyper.Parameters{{Name: "reason", Type: hyper.TypeText, Required: true}},
+		},
+		isOpen,
+		func(r *http.Request, c hyper.Command) (hyper.Item, error) {
+			state = "cancelled"
+			return hyper.Item{}, nil
+		},
+	).Action(
+		hyper.Action{Rel: "pay", Method: http.MethodPut},
+		nil,
+		func(r *http.Request, c hyper.Command) (hyper.Item, error) {
+			return hyper.Item{Label: "receipt"}, nil
+		},
+	)
+
+	tests := []struct {
+		name          string
+		state         string
+		method        string
+		accept        string
+		body          string
+		expectStatus  int
+		expectLabel   string
+		expectActions []string
+		expectAllow   string
+		expectErrors  []string
+	}{
+		{
+			name:          "get",
+			state:         "open",
+			method:        http.MethodGet,
+			expectStatus:  http.StatusOK,
+			expectLabel:   "open",
+			expectActions: []string{"cancel", "pay"},
+		},
+		{
+			name:          "get-unavailable-action",
+			state:         "closed",
+			method:        http.MethodGet,
+			accept:        hyper.ContentTypeJSON,
+			expectStatus:  http.StatusOK,
+			expectLabel:   "closed",
+			expectActions: []string{"pay"},
+		},
+		{
+			name:         "not-acceptable",
+			state:        "open",
+			method:       http.MethodGet,
+			accept:       "text/html, application/json;q=0",
+			expectStatus: http.StatusNotAcceptable,
+		},
+		{
+			name:         "method-not-allowed",
+			state:        "open",
+			method:       http.MethodPost,
+			body:         `{"@action":"pay"}`,
+			expectStatus: http.StatusMethodNotAllowed,
+			expectAllow:  "GET, HEAD, POST, PUT",
+		},
+		{
+			name:         "unavailable",
+			state:        "closed",
+			method:       http.MethodPost,
+			body:         `{"@action":"cancel","reason":"too late"}`,
+			expectStatus: http.StatusConflict,
+		},
+		{
+			name:         "unknown",
+			state:        "open",
+			method:       http.MethodPost,
+			body:         `{"@action":"ship"}`,
+			expectStatus: http.StatusBadRequest,
+			expectErrors: []string{hyper.CodeUnknownAction + " (valid actions: cancel, pay)"},
+		},
+		{
+			name:         "invalid",
+			state:        "open",
+			method:       http.MethodPost,
+			body:         `{"@action":"cancel"}`,
+			expectStatus: http.StatusBadRequest,
+			expectErrors: []string{hyper.CodeRequired},
+		},
+		{
+			// the handler returns the zero Item, so the new state is represented
+			name:          "empty-result",
+			state:         "open",
+			method:        http.MethodPost,
+			body:          `{"@action":"cancel","reason":"changed my mind"}`,
+			expectStatus:  http.StatusOK,
+			expectLabel:   "cancelled",
+			expectActions: []string{"pay"},
+		},
+		{
+			name:         "result",
+			state:        "open",
+			method:       http.MethodPut,
+			body:         `{"@action":"pay"}`,
+			expectStatus: http.StatusOK,
+			expectLabel:  "receipt",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state = test.state
+			r := httptest.NewRequest(test.method, "/orders/1", strings.NewReader(test.body))
+			r.Header.Set(hyper.HeaderAccept, test.accept)
+			if test.body != "" {
+				r.Header.Set(hyper.HeaderContentType, hyper.ContentTypeJSON)
+			}
+			w := httptest.NewRecorder()
+			res.ServeHTTP(w, r)
+			if test.expectStatus != w.Code {
+				t.Errorf("want: %d, got: %d", test.expectStatus, w.Code)
+			}
+			if want, got := test.expectAllow, w.Header().Get(hyper.HeaderAllow); want != got {
+				t.Errorf("want: %q, got: %q", want, got)
+			}
+			var i hyper.Item
+			if err := json.NewDecoder(w.Body).Decode(&i); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.expectLabel != i.Label {
+				t.Errorf("want: %s, got: %s", test.expectLabel, i.Label)
+			}
+			var actions []string
+			for _, a := range i.Actions {
+				actions = append(actions, a.Rel)
+				if a.Href != "/orders/1" {
+					t.Errorf("%s: want: /orders/1, got: %s", a.Rel, a.Href)
+				}
+				if _, ok := a.Parameters.FindByName(hyper.NameAction); !ok {
+					t.Errorf("%s: want: %s parameter, got: none", a.Rel, hyper.NameAction)
+				}
+			}
+			if !reflect.DeepEqual(test.expectActions, actions) {
+				t.Errorf("want: %v, got: %v", test.expectActions, actions)
+			}
+			if test.expectActions != nil {
+				if self, ok := i.Links.FindByRel(hyper.RelSelf); !ok || self.Href != "/orders/1" {
+					t.Errorf("want: self link /orders/1, got: %#v", i.Links)
+				}
+			}
+			var errs []string
+			for _, e := range i.Errors {
+				switch {
+				case e.Code != "" && e.Description != "":
+					errs = append(errs, e.Code+" ("+e.Description+")")
+				case e.Code != "":
+					errs = append(errs, e.Code)
+				}
+			}
+			if test.expectErrors != nil && !reflect.DeepEqual(test.expectErrors, errs) {
+				t.Errorf("want: %v, got: %v", test.expectErrors, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package hyper
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how a Client retries requests that failed due to network errors or
+// temporary server conditions. By default only idempotent requests are retried: requests with
+// an idempotent method (see IsIdempotent) and submissions of an Action that is marked as Idempotent.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one. Defaults to 3.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry. Defaults to 100ms.
+	InitialBackoff time.Duration
+	// MaxBackoff limits the delay between two attempts. A Retry-After that exceeds it is not
+	// waited for. Defaults to 10s.
+	MaxBackoff time.Duration
+	// Multiplier is the factor by which the delay grows with each attempt. Defaults to 2.
+	Multiplier float64
+	// Jitter randomizes the delay by up to the given fraction, e.g. 0.2 for ±20%.
+	Jitter float64
+	// Retryable decides whether a failed attempt is retried. Defaults to DefaultRetryable.
+	Retryable func(r *http.Request, resp *http.Response, err error) bool
+	// OnRetry is called before an attempt is retried, e.g. to log it.
+	OnRetry func(RetryAttempt)
+}
+
+// RetryAttempt describes a failed attempt that is about to be retried.
+type RetryAttempt struct {
+	Request *http.Request
+	// Attempt is the number of the failed attempt, starting with 1.
+	Attempt    int
+	StatusCode int
+	Err        error
+	// Delay is the time that is waited for before the next attempt.
+	Delay time.Duration
+}
+
+// WithRetry makes the Client retry failed requests according to the RetryPolicy.
+func WithRetry(p RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retry = &p
+	}
+}
+
+// IsIdempotent reports whether requests with the given method may be repeated without
+// changing the outcome (RFC 7231, 4.2.2).
+func IsIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// DefaultRetryable retries idempotent requests that failed with a network error or with one
+// of the status codes 408, 429, 502, 503 and 504.
+func DefaultRetryable(r *http.Request, resp *http.Response, err error) bool {
+	if !IsIdempotent(r.Method) && !isIdempotentContext(r.Context()) {
+		return false
+	}
+	if err != nil {
+		return r.Context().Err() == nil
+	}
+	switch resp.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+type idempotentKey struct{}
+
+// withIdempotent marks requests that are sent with the context as idempotent.
+func withIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+func isIdempotentContext(ctx context.Context) bool {
+	v, _ := ctx.Value(idempotentKey{}).(bool)
+	return v
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = 100 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 10 * time.Second
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = 2
+	}
+	if p.Retryable == nil {
+		p.Retryable = DefaultRetryable
+	}
+	return p
+}
+
+// backoff calculates the delay after the given failed attempt. The jittered delay never
+// exceeds MaxBackoff.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		d *= p.Multiplier
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+	if d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	return time.Duration(d)
+}
+
+func (p RetryPolicy) middleware(next http.RoundTripper) http.RoundTripper {
+	p = p.withDefaults()
+	return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		replayable := r.Body == nil || r.Body == http.NoBody || r.GetBody != nil
+		for attempt := 1; ; attempt++ {
+			req := r
+			if attempt > 1 && r.GetBody != nil {
+				body, err := r.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				req = r.Clone(r.Context())
+				req.Body = body
+			}
+			resp, err := next.RoundTrip(req)
+			if attempt >= p.MaxAttempts || !replayable || !p.Retryable(r, resp, err) {
+				return resp, err
+			}
+			delay := p.backoff(attempt)
+			if ra, ok := retryAfter(resp); ok {
+				if ra > p.MaxBackoff {
+					return resp, err
+				}
+				if ra > delay {
+					delay = ra
+				}
+			}
+			a := RetryAttempt{
+				Request: r,
+				Attempt: attempt,
+				Err:     err,
+				Delay:   delay,
+			}
+			if resp != nil {
+				a.StatusCode = resp.StatusCode
+				io.Copy(ioutil.Discard, resp.Body)
+				resp.Body.Close()
+			}
+			if p.OnRetry != nil {
+				p.OnRetry(a)
+			}
+			t := time.NewTimer(delay)
+			select {
+			case <-r.Context().Done():
+				t.Stop()
+				return nil, r.Context().Err()
+			case <-t.C:
+			}
+		}
+	})
+}
+
+// retryAfter parses the Retry-After header of the response (RFC 7231, 7.1.3).
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	v := resp.Header.Get(HeaderRetryAfter)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
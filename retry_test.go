@@ -0,0 +1,215 @@
+package hyper_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cognicraft/hyper"
+)
+
+func TestClientRetry(t *testing.T) {
+	type result struct {
+		status int
+		header http.Header
+		err    error
+	}
+	unavailable := result{status: http.StatusServiceUnavailable}
+	ok := result{status: http.StatusOK}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tests := []struct {
+		name           string
+		policy         hyper.RetryPolicy
+		results        []result
+		do             func(c *hyper.Client) error
+		expectAttempts int
+		expectErr      bool
+	}{
+		{
+			name:    "get",
+			results: []result{unavailable, unavailable, ok},
+			do: func(c *hyper.Client) error {
+				_, err := c.Fetch("http://example.com/orders")
+				return err
+			},
+			expectAttempts: 3,
+		},
+		{
+			name:    "get-max-attempts",
+			results: []result{unavailable, unavailable, unavailable, ok},
+			do: func(c *hyper.Client) error {
+				_, err := c.Fetch("http://example.com/orders")
+				return err
+			},
+			expectAttempts: 3,
+			expectErr:      true,
+		},
+		{
+			name:    "get-network-error",
+			results: []result{{err: errors.New("connection reset")}, ok},
+			do: func(c *hyper.Client) error {
+				_, err := c.Fetch("http://example.com/orders")
+				return err
+			},
+			expectAttempts: 2,
+		},
+		{
+			name:    "idempotent-action",
+			results: []result{unavailable, ok},
+			do: func(c *hyper.Client) error {
+				_, err := c.Submit(context.Background(), hyper.Action{Href: "http://example.com/orders", Idempotent: true}, hyper.Arguments{"n": 1})
+				return err
+			},
+			expectAttempts: 2,
+		},
+		{
+			name:    "delete-action",
+			results: []result{unavailable, ok},
+			do: func(c *hyper.Client) error {
+				_, err := c.Submit(context.Background(), hyper.Action{Href: "http://example.com/orders/1", Method: hyper.MethodDELETE}, nil)
+				return err
+			},
+			expectAttempts: 2,
+		},
+		{
+			name:    "post-action",
+			results: []result{unavailable, ok},
+			do: func(c *hyper.Client) error {
+				_, err := c.Submit(context.Background(), hyper.Action{Href: "http://example.com/orders"}, hyper.Arguments{"n": 1})
+				return err
+			},
+			expectAttempts: 1,
+			expectErr:      true,
+		},
+		{
+			name:   "retry-after-exceeds-max-backoff",
+			policy: hyper.RetryPolicy{MaxBackoff: time.Second},
+			results: []result{
+				{status: http.StatusServiceUnavailable, header: http.Header{hyper.HeaderRetryAfter: {"60"}}},
+				ok,
+			},
+			do: func(c *hyper.Client) error {
+				_, err := c.Fetch("http://example.com/orders")
+				return err
+			},
+			expectAttempts: 1,
+			expectErr:      true,
+		},
+		{
+			name:    "body-without-get-body",
+			results: []result{unavailable, ok},
+			do: func(c *hyper.Client) error {
+				req, err := http.NewRequest(http.MethodPut, "http://example.com/orders/1", ioutil.NopCloser(strings.NewReader(`{"n":1}`)))
+				if err != nil {
+					return err
+				}
+				_, err = c.Do(req)
+				return err
+			},
+			expectAttempts: 1,
+			expectErr:      true,
+		},
+		{
+			name: "cancel-during-backoff",
+			policy: hyper.RetryPolicy{
+				InitialBackoff: time.Hour,
+				OnRetry:        func(hyper.RetryAttempt) { cancel() },
+			},
+			results: []result{unavailable, ok},
+			do: func(c *hyper.Client) error {
+				req, err := http.NewRequest(hyper.MethodGET, "http://example.com/orders", nil)
+				if err != nil {
+					return err
+				}
+				_, err = c.Do(req.WithContext(ctx))
+				return err
+			},
+			expectAttempts: 1,
+			expectErr:      true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attempts := 0
+			var bodies []string
+			transport := hyper.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				res := test.results[attempts]
+				attempts++
+				if r.Body != nil {
+					bs, _ := ioutil.ReadAll(r.Body)
+					bodies = append(bodies, string(bs))
+				}
+				if res.err != nil {
+					return nil, res.err
+				}
+				header := http.Header{hyper.HeaderContentType: {hyper.ContentTypeHyperItem}}
+				for k, vs := range res.header {
+					header[k] = vs
+				}
+				return &http.Response{
+					StatusCode: res.status,
+					Header:     header,
+					Body:       ioutil.NopCloser(strings.NewReader("{}")),
+					Request:    r,
+				}, nil
+			})
+			policy := test.policy
+			if policy.InitialBackoff == 0 {
+				policy.InitialBackoff = time.Millisecond
+			}
+			c := hyper.NewClient(hyper.WithTransport(transport), hyper.WithRetry(policy))
+			err := test.do(c)
+			if test.expectErr && err == nil {
+				t.Errorf("want: error, got: nil")
+			}
+			if !test.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if test.expectAttempts != attempts {
+				t.Errorf("want: %d attempts, got: %d", test.expectAttempts, attempts)
+			}
+			// every attempt must send the complete body
+			for i, b := range bodies {
+				if b != bodies[0] {
+					t.Errorf("attempt %d: want: %s, got: %s", i+1, bodies[0], b)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryJitterMaxBackoff(t *testing.T) {
+	maxBackoff := 2 * time.Millisecond
+	var delays []time.Duration
+	transport := hyper.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+			Request:    r,
+		}, nil
+	})
+	c := hyper.NewClient(hyper.WithTransport(transport), hyper.WithRetry(hyper.RetryPolicy{
+		MaxAttempts:    20,
+		InitialBackoff: maxBackoff,
+		MaxBackoff:     maxBackoff,
+		Jitter:         1,
+		OnRetry: func(a hyper.RetryAttempt) {
+			delays = append(delays, a.Delay)
+		},
+	}))
+	if _, err := c.Fetch("http://example.com/orders"); err == nil {
+		t.Fatalf("want: error, got: nil")
+	}
+	for k, d := range delays {
+		if d > maxBackoff {
+			t.Errorf("attempt %d: want: <= %s, got: %s", k+1, maxBackoff, d)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package hyper
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// CodeUnknownAction is the Code of the Error that is written by a CommandRouter for unknown actions.
+const CodeUnknownAction = "unknown-action"
+
+// CommandHandler executes a Command and returns the resulting Item.
+type CommandHandler func(r *http.Request, c Command) (Item, error)
+
+// CommandMiddleware wraps a CommandHandler, e.g. to check permissions or preconditions.
+type CommandMiddleware func(next CommandHandler) CommandHandler
+
+// CommandRouter is an http.Handler that reads the Command of a request with ReadCommand and
+// dispatches it to the CommandHandler that is registered for its action, i.e. the value of
+// the NameAction parameter (see ActionParameter).
+//
+// The Item returned by the handler is written with 200 OK. Errors are written with WriteError
+// using the status of ErrorStatus, which defaults to 500 Internal Server Error; FieldErrors,
+// e.g. from Command.Bind, are written with 400 Bad Request and one Error per field. Commands
+// with an unknown action are answered with 400 Bad Request and an Error that lists the valid
+// actions.
+type CommandRouter struct {
+	handlers   map[string]CommandHandler
+	middleware []CommandMiddleware
+}
+
+// NewCommandRouter creates an empty CommandRouter.
+func NewCommandRouter() *CommandRouter {
+	return &CommandRouter{
+		handlers: map[string]CommandHandler{},
+	}
+}
+
+// Use adds CommandMiddleware that applies to all actions. The first one is the outermost one.
+func (cr *CommandRouter) Use(mws ...CommandMiddleware) {
+	cr.middleware = append(cr.middleware, mws...)
+}
+
+// Handle registers the CommandHandler for an action, wrapped by the given CommandMiddleware.
+func (cr *CommandRouter) Handle(action string, h CommandHandler, mws ...CommandMiddleware) {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	cr.handlers[action] = h
+}
+
+// Actions returns the sorted names of the registered actions.
+func (cr *CommandRouter) Actions() []string {
+	names := make([]string, 0, len(cr.handlers))
+	for n := range cr.handlers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (cr *CommandRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c, err := ReadCommand(r)
+	if err != nil {
+		WriteError(w, ErrorStatus(err, http.StatusBadRequest), err)
+		return
+	}
+	h, ok := cr.handlers[c.Action]
+	if !ok {
+		Write(w, http.StatusBadRequest, Item{
+			Errors: Errors{
+				{
+					Message:     fmt.Sprintf("unknown action %q", c.Action),
+					Description: "valid actions: " + strings.Join(cr.Actions(), ", "),
+					Code:        CodeUnknownAction,
+				},
+			},
+		})
+		return
+	}
+	for i := len(cr.middleware) - 1; i >= 0; i-- {
+		h = cr.middleware[i](h)
+	}
+	res, err := h(r, c)
+	if err != nil {
+		writeCommandError(w, err)
+		return
+	}
+	Write(w, http.StatusOK, res)
+}
+
+func writeCommandError(w http.ResponseWriter, err error) {
+	var fes FieldErrors
+	if errors.As(err, &fes) {
+		Write(w, ErrorStatus(err, http.StatusBadRequest), Item{Errors: fes.Errors()})
+		return
+	}
+	WriteError(w, ErrorStatus(err, http.StatusInternalServerError), err)
+}
@@ -0,0 +1,127 @@
+package hyper_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cognicraft/hyper"
+)
+
+func TestCommandRouter(t *testing.T) {
+	trace := func(name string) hyper.CommandMiddleware {
+		return func(next hyper.CommandHandler) hyper.CommandHandler {
+			return func(r *http.Request, c hyper.Command) (hyper.Item, error) {
+				res, err := next(r, c)
+				res.Description = name + ">" + res.Description
+				return res, err
+			}
+		}
+	}
+	cr := hyper.NewCommandRouter()
+	cr.Use(trace("first"), trace("second"))
+	cr.Handle("rename", func(r *http.Request, c hyper.Command) (hyper.Item, error) {
+		var args struct {
+			Name  string `hyper:"name"`
+			Count int    `hyper:"count"`
+		}
+		if err := c.Bind(&args); err != nil {
+			return hyper.Item{}, err
+		}
+		return hyper.Item{Label: args.Name, Description: "rename"}, nil
+	})
+	cr.Handle("archive", func(r *http.Request, c hyper.Command) (hyper.Item, error) {
+		return hyper.Item{Description: "archive"}, nil
+	}, trace("local"))
+	cr.Handle("fail", func(r *http.Request, c hyper.Command) (hyper.Item, error) {
+		return hyper.Item{}, fmt.Errorf("boom")
+	})
+
+	tests := []struct {
+		name              string
+		body              string
+		expectStatus      int
+		expectLabel       string
+		expectDescription string
+		expectErrors      []string
+	}{
+		{
+			name:              "dispatch",
+			body:              `{"@action":"rename","name":"foo"}`,
+			expectStatus:      http.StatusOK,
+			expectLabel:       "foo",
+			expectDescription: "first>second>rename",
+		},
+		{
+			name:              "middleware-order",
+			body:              `{"@action":"archive"}`,
+			expectStatus:      http.StatusOK,
+			expectDescription: "first>second>local>archive",
+		},
+		{
+			name:         "unknown-action",
+			body:         `{"@action":"delete"}`,
+			expectStatus: http.StatusBadRequest,
+			expectErrors: []string{"unknown-action: valid actions: archive, fail, rename"},
+		},
+		{
+			name:         "field-errors",
+			body:         `{"@action":"rename","name":"foo","count":"many"}`,
+			expectStatus: http.StatusBadRequest,
+			expectErrors: []string{`count: invalid integer "many"`},
+		},
+		{
+			name:         "error",
+			body:         `{"@action":"fail"}`,
+			expectStatus: http.StatusInternalServerError,
+			expectErrors: []string{"boom"},
+		},
+		{
+			name:         "malformed",
+			body:         `{"@action":`,
+			expectStatus: http.StatusBadRequest,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/orders/1", strings.NewReader(test.body))
+			r.Header.Set(hyper.HeaderContentType, hyper.ContentTypeJSON)
+			w := httptest.NewRecorder()
+			cr.ServeHTTP(w, r)
+			if test.expectStatus != w.Code {
+				t.Errorf("want: %d, got: %d", test.expectStatus, w.Code)
+			}
+			var res hyper.Item
+			if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.expectLabel != res.Label {
+				t.Errorf("want: %s, got: %s", test.expectLabel, res.Label)
+			}
+			if test.expectDescription != res.Description {
+				t.Errorf("want: %s, got: %s", test.expectDescription, res.Description)
+			}
+			if test.expectErrors == nil {
+				return
+			}
+			var got []string
+			for _, e := range res.Errors {
+				switch {
+				case e.Code == hyper.CodeUnknownAction:
+					got = append(got, e.Code+": "+e.Description)
+				case e.Label != "":
+					got = append(got, e.Label+": "+e.Message)
+				default:
+					got = append(got, e.Message)
+				}
+			}
+			if !reflect.DeepEqual(test.expectErrors, got) {
+				t.Errorf("want: %v, got: %v", test.expectErrors, got)
+			}
+		})
+	}
+}
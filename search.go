@@ -1,15 +1,164 @@
 package hyper
 
+import (
+	"strconv"
+	"strings"
+)
+
 // Search performs a DFS with the goal to find an item by the specified id
 func Search(root Item, id string) (Item, bool) {
-	frontier := []Item{root}
+	ms := SearchItems(root, ItemIDEquals(id), SearchOptions{})
+	if len(ms) == 0 {
+		return Item{}, false
+	}
+	return ms[0].Item, true
+}
+
+// SearchOrder is the order in which the Items of a tree are visited.
+type SearchOrder int
+
+const (
+	// DepthFirst visits an Item before its sub-items and each sub-tree before its next sibling.
+	DepthFirst SearchOrder = iota
+	// BreadthFirst visits all Items of a depth before the Items of the next depth.
+	BreadthFirst
+)
+
+// SearchOptions control a search of an Item tree.
+type SearchOptions struct {
+	// Order is the traversal order, DepthFirst by default.
+	Order SearchOrder
+	// MaxDepth limits the depth of the visited Items. The root has depth 0, its sub-items
+	// depth 1 and so on. Zero means unlimited.
+	MaxDepth int
+	// All returns all matches instead of the first one.
+	All bool
+}
+
+// PathSegment identifies a sub-item by its index in the Items of its parent and its ID.
+type PathSegment struct {
+	Index int
+	ID    string
+}
+
+// ItemPath is the chain of sub-items from the root of a tree to an Item. The root has an
+// empty ItemPath.
+type ItemPath []PathSegment
+
+// IDs returns the IDs of the Items along the path, excluding the root.
+func (p ItemPath) IDs() []string {
+	ids := make([]string, len(p))
+	for i, s := range p {
+		ids[i] = s.ID
+	}
+	return ids
+}
+
+// String returns the path as a query, e.g. ".items[1].items[0]" or "." for the root.
+func (p ItemPath) String() string {
+	if len(p) == 0 {
+		return "."
+	}
+	var b strings.Builder
+	for _, s := range p {
+		b.WriteString(".items[")
+		b.WriteString(strconv.Itoa(s.Index))
+		b.WriteString("]")
+	}
+	return b.String()
+}
+
+// Match is a result of a search. For Link and Action searches, Item is the Item that contains
+// the matching Link or Action.
+type Match struct {
+	Path   ItemPath
+	Item   Item
+	Link   *Link
+	Action *Action
+}
+
+// SearchItems returns the Items of the tree below and including root that satisfy match.
+func SearchItems(root Item, match func(Item) bool, opts SearchOptions) []Match {
+	var res []Match
+	traverse(root, opts, func(i Item, p ItemPath) bool {
+		if match(i) {
+			res = append(res, Match{Path: p, Item: i})
+		}
+		return opts.All || len(res) == 0
+	})
+	return res
+}
+
+// SearchLinks returns the Links of all Items of the tree that satisfy match.
+func SearchLinks(root Item, match func(Link) bool, opts SearchOptions) []Match {
+	var res []Match
+	traverse(root, opts, func(i Item, p ItemPath) bool {
+		for k := range i.Links {
+			if match(i.Links[k]) {
+				l := i.Links[k]
+				res = append(res, Match{Path: p, Item: i, Link: &l})
+				if !opts.All {
+					return false
+				}
+			}
+		}
+		return true
+	})
+	return res
+}
+
+// SearchActions returns the Actions of all Items of the tree that satisfy match.
+func SearchActions(root Item, match func(Action) bool, opts SearchOptions) []Match {
+	var res []Match
+	traverse(root, opts, func(i Item, p ItemPath) bool {
+		for k := range i.Actions {
+			if match(i.Actions[k]) {
+				a := i.Actions[k]
+				res = append(res, Match{Path: p, Item: i, Action: &a})
+				if !opts.All {
+					return false
+				}
+			}
+		}
+		return true
+	})
+	return res
+}
+
+type searchNode struct {
+	item Item
+	path ItemPath
+}
+
+// traverse visits the Items of the tree in the order of opts until visit returns false.
+func traverse(root Item, opts SearchOptions, visit func(i Item, p ItemPath) bool) {
+	frontier := []searchNode{{item: root}}
 	for len(frontier) > 0 {
-		var next Item
-		next, frontier = frontier[0], frontier[1:]
-		if next.ID == id {
-			return next, true
+		var n searchNode
+		if opts.Order == BreadthFirst {
+			n, frontier = frontier[0], frontier[1:]
+		} else {
+			n, frontier = frontier[len(frontier)-1], frontier[:len(frontier)-1]
+		}
+		if !visit(n.item, n.path) {
+			return
+		}
+		if opts.MaxDepth > 0 && len(n.path) >= opts.MaxDepth {
+			continue
+		}
+		children := make([]searchNode, len(n.item.Items))
+		for k, sub := range n.item.Items {
+			p := make(ItemPath, len(n.path), len(n.path)+1)
+			copy(p, n.path)
+			children[k] = searchNode{item: sub, path: append(p, PathSegment{Index: k, ID: sub.ID})}
+		}
+		if opts.Order == BreadthFirst {
+			frontier = append(frontier, children...)
+			continue
+		}
+		// the frontier is a stack: push in reverse so that the first sub-item is visited next
+		for k := len(children) - 1; k >= 0; k-- {
+			frontier = append(frontier, children[k])
 		}
-		frontier = append(next.Items, frontier...)
 	}
-	return Item{}, false
 }
@@ -82,3 +82,128 @@ func TestSearch(t *testing.T) {
 		})
 	}
 }
+
+func TestSearchItems(t *testing.T) {
+	root := hyper.Item{
+		ID: "root",
+		Items: hyper.Items{
+			{
+				ID:    "a",
+				Type:  "order",
+				Items: hyper.Items{{ID: "a.1", Type: "line"}, {ID: "a.2", Type: "line"}},
+			},
+			{
+				ID:    "b",
+				Type:  "order",
+				Items: hyper.Items{{ID: "b.1", Type: "line"}},
+			},
+		},
+	}
+	any := func(hyper.Item) bool { return true }
+
+	tests := []struct {
+		name  string
+		match func(hyper.Item) bool
+		opts  hyper.SearchOptions
+		ids   []string
+		paths []string
+	}{
+		{
+			name:  "dfs",
+			match: any,
+			opts:  hyper.SearchOptions{All: true},
+			ids:   []string{"root", "a", "a.1", "a.2", "b", "b.1"},
+			paths: []string{".", ".items[0]", ".items[0].items[0]", ".items[0].items[1]", ".items[1]", ".items[1].items[0]"},
+		},
+		{
+			name:  "bfs",
+			match: any,
+			opts:  hyper.SearchOptions{Order: hyper.BreadthFirst, All: true},
+			ids:   []string{"root", "a", "b", "a.1", "a.2", "b.1"},
+			paths: []string{".", ".items[0]", ".items[1]", ".items[0].items[0]", ".items[0].items[1]", ".items[1].items[0]"},
+		},
+		{
+			name:  "max-depth",
+			match: any,
+			opts:  hyper.SearchOptions{Order: hyper.BreadthFirst, MaxDepth: 1, All: true},
+			ids:   []string{"root", "a", "b"},
+			paths: []string{".", ".items[0]", ".items[1]"},
+		},
+		{
+			name:  "first-dfs",
+			match: hyper.ItemTypeEquals("line"),
+			opts:  hyper.SearchOptions{},
+			ids:   []string{"a.1"},
+			paths: []string{".items[0].items[0]"},
+		},
+		{
+			name:  "first-bfs",
+			match: hyper.ItemIDEquals("b.1"),
+			opts:  hyper.SearchOptions{Order: hyper.BreadthFirst},
+			ids:   []string{"b.1"},
+			paths: []string{".items[1].items[0]"},
+		},
+		{
+			name:  "none",
+			match: hyper.ItemTypeEquals("line"),
+			opts:  hyper.SearchOptions{MaxDepth: 1, All: true},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var ids, paths []string
+			for _, m := range hyper.SearchItems(root, test.match, test.opts) {
+				ids = append(ids, m.Item.ID)
+				paths = append(paths, m.Path.String())
+			}
+			if !reflect.DeepEqual(test.ids, ids) {
+				t.Errorf("want: %v, got: %v", test.ids, ids)
+			}
+			if !reflect.DeepEqual(test.paths, paths) {
+				t.Errorf("want: %v, got: %v", test.paths, paths)
+			}
+		})
+	}
+}
+
+func TestSearchLinksAndActions(t *testing.T) {
+	root := hyper.Item{
+		ID:    "root",
+		Links: hyper.Links{{Rel: "self", Href: "/"}},
+		Items: hyper.Items{
+			{
+				ID:      "a",
+				Links:   hyper.Links{{Rel: "self", Href: "/a"}, {Rel: "next", Href: "/b"}},
+				Actions: hyper.Actions{{Rel: "cancel", Href: "/a"}},
+			},
+			{
+				ID:      "b",
+				Items:   hyper.Items{{ID: "b.1", Links: hyper.Links{{Rel: "self", Href: "/b/1"}}}},
+				Actions: hyper.Actions{{Rel: "cancel", Href: "/b"}},
+			},
+		},
+	}
+
+	var hrefs []string
+	for _, m := range hyper.SearchLinks(root, hyper.LinkRelEquals("self"), hyper.SearchOptions{All: true}) {
+		hrefs = append(hrefs, m.Link.Href)
+	}
+	if want := []string{"/", "/a", "/b/1"}; !reflect.DeepEqual(want, hrefs) {
+		t.Errorf("want: %v, got: %v", want, hrefs)
+	}
+
+	ms := hyper.SearchLinks(root, hyper.LinkRelEquals("next"), hyper.SearchOptions{})
+	if len(ms) != 1 || ms[0].Item.ID != "a" || !reflect.DeepEqual([]string{"a"}, ms[0].Path.IDs()) {
+		t.Errorf("unexpected matches: %+v", ms)
+	}
+
+	ms = hyper.SearchActions(root, hyper.ActionRelEquals("cancel"), hyper.SearchOptions{All: true})
+	if len(ms) != 2 || ms[0].Action.Href != "/a" || ms[1].Action.Href != "/b" || ms[1].Path.String() != ".items[1]" {
+		t.Errorf("unexpected matches: %+v", ms)
+	}
+
+	ms = hyper.SearchActions(root, hyper.ActionRelEquals("cancel"), hyper.SearchOptions{Order: hyper.BreadthFirst})
+	if len(ms) != 1 || ms[0].Item.ID != "a" {
+		t.Errorf("unexpected matches: %+v", ms)
+	}
+}
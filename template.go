@@ -0,0 +1,494 @@
+package hyper
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// URITemplate is a parsed URI template as specified by RFC 6570 (up to level 4).
+// See: https://tools.ietf.org/html/rfc6570
+type URITemplate struct {
+	raw     string
+	parts   []templatePart
+	matcher *regexp.Regexp
+}
+
+// ParseURITemplate parses a URI template.
+func ParseURITemplate(t string) (*URITemplate, error) {
+	ut := &URITemplate{raw: t}
+	rest := t
+	for len(rest) > 0 {
+		start := strings.IndexAny(rest, "{}")
+		if start < 0 {
+			ut.parts = append(ut.parts, templatePart{literal: rest})
+			break
+		}
+		if rest[start] == '}' {
+			return nil, fmt.Errorf("template %q: unexpected '}'", t)
+		}
+		if start > 0 {
+			ut.parts = append(ut.parts, templatePart{literal: rest[:start]})
+		}
+		end := strings.Index(rest[start:], "}")
+		if end < 0 {
+			return nil, fmt.Errorf("template %q: unclosed expression", t)
+		}
+		e, err := parseTemplateExpression(rest[start+1 : start+end])
+		if err != nil {
+			return nil, fmt.Errorf("template %q: %v", t, err)
+		}
+		ut.parts = append(ut.parts, templatePart{expression: e})
+		rest = rest[start+end+1:]
+	}
+	var buf bytes.Buffer
+	buf.WriteString("^")
+	for _, p := range ut.parts {
+		if p.expression == nil {
+			buf.WriteString(regexp.QuoteMeta(encodeTemplateLiteral(p.literal)))
+			continue
+		}
+		buf.WriteString(p.expression.op.pattern)
+	}
+	buf.WriteString("$")
+	ut.matcher = regexp.MustCompile(buf.String())
+	return ut, nil
+}
+
+// MustParseURITemplate is like ParseURITemplate but panics if the template cannot be parsed.
+func MustParseURITemplate(t string) *URITemplate {
+	ut, err := ParseURITemplate(t)
+	if err != nil {
+		panic(err)
+	}
+	return ut
+}
+
+// ExpandURITemplate parses and expands a URI template with the given Arguments.
+func ExpandURITemplate(t string, args Arguments) (string, error) {
+	ut, err := ParseURITemplate(t)
+	if err != nil {
+		return "", err
+	}
+	return ut.Expand(args)
+}
+
+// String returns the source text of the template.
+func (ut *URITemplate) String() string {
+	return ut.raw
+}
+
+// Names returns the names of all variables of the template in order of appearance.
+func (ut *URITemplate) Names() []string {
+	var names []string
+	for _, p := range ut.parts {
+		if p.expression == nil {
+			continue
+		}
+		for _, v := range p.expression.vars {
+			names = append(names, v.name)
+		}
+	}
+	return names
+}
+
+// Expand expands the template with the given Arguments. Strings, numbers and booleans are
+// expanded as simple values, slices as lists and maps as associative arrays. Missing and nil
+// values are undefined and are skipped.
+func (ut *URITemplate) Expand(args Arguments) (string, error) {
+	var buf bytes.Buffer
+	for _, p := range ut.parts {
+		if p.expression == nil {
+			buf.WriteString(encodeTemplateLiteral(p.literal))
+			continue
+		}
+		if err := p.expression.expand(&buf, args); err != nil {
+			return "", fmt.Errorf("template %q: %v", ut.raw, err)
+		}
+	}
+	return buf.String(), nil
+}
+
+// Match matches the URI against the template and extracts the values of the variables.
+// Single values are returned as string, lists as []string and associative arrays of
+// exploded variables as map[string]interface{}. Since expansion is not generally
+// reversible, matching is a best effort that works well for the templates that are
+// typically used to build resource URIs.
+func (ut *URITemplate) Match(uri string) (Arguments, bool) {
+	m := ut.matcher.FindStringSubmatch(uri)
+	if m == nil {
+		return nil, false
+	}
+	args := Arguments{}
+	i := 1
+	for _, p := range ut.parts {
+		if p.expression == nil {
+			continue
+		}
+		if !p.expression.match(m[i], args) {
+			return nil, false
+		}
+		i++
+	}
+	return args, true
+}
+
+type templatePart struct {
+	literal    string
+	expression *templateExpression
+}
+
+type templateExpression struct {
+	op   templateOperator
+	vars []templateVar
+}
+
+type templateVar struct {
+	name    string
+	prefix  int
+	explode bool
+}
+
+type templateOperator struct {
+	first         string
+	sep           string
+	named         bool
+	ifEmpty       string
+	allowReserved bool
+	pattern       string
+}
+
+var templateOperators = map[byte]templateOperator{
+	'+': {first: "", sep: ",", allowReserved: true, pattern: `(.*?)`},
+	'#': {first: "#", sep: ",", allowReserved: true, pattern: `((?:#.*)?)`},
+	'.': {first: ".", sep: ".", pattern: `((?:\.[^/?#.]*)*)`},
+	'/': {first: "/", sep: "/", pattern: `((?:/[^/?#]*?)*)`},
+	';': {first: ";", sep: ";", named: true, pattern: `((?:;[^/?#;]*)*)`},
+	'?': {first: "?", sep: "&", named: true, ifEmpty: "=", pattern: `((?:\?[^#]*)?)`},
+	'&': {first: "&", sep: "&", named: true, ifEmpty: "=", pattern: `((?:&[^#]*)?)`},
+}
+
+var templateSimpleOperator = templateOperator{first: "", sep: ",", pattern: `([^/?#]*)`}
+
+func parseTemplateExpression(s string) (*templateExpression, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty expression")
+	}
+	e := &templateExpression{op: templateSimpleOperator}
+	if op, ok := templateOperators[s[0]]; ok {
+		e.op = op
+		s = s[1:]
+	} else if strings.ContainsRune("=,!@|", rune(s[0])) {
+		return nil, fmt.Errorf("reserved operator %q", s[0])
+	}
+	for _, spec := range strings.Split(s, ",") {
+		v := templateVar{name: spec}
+		switch {
+		case strings.HasSuffix(spec, "*"):
+			v.name = spec[:len(spec)-1]
+			v.explode = true
+		case strings.Contains(spec, ":"):
+			i := strings.Index(spec, ":")
+			v.name = spec[:i]
+			n, err := strconv.Atoi(spec[i+1:])
+			if err != nil || n <= 0 || n >= 10000 {
+				return nil, fmt.Errorf("invalid prefix in %q", spec)
+			}
+			v.prefix = n
+		}
+		if !validTemplateVarName(v.name) {
+			return nil, fmt.Errorf("invalid variable name %q", v.name)
+		}
+		e.vars = append(e.vars, v)
+	}
+	return e, nil
+}
+
+func validTemplateVarName(name string) bool {
+	if name == "" || name[0] == '.' || name[len(name)-1] == '.' {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '_', c == '.':
+		case c == '%' && i+2 < len(name) && isHex(name[i+1]) && isHex(name[i+2]):
+			i += 2
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (e *templateExpression) expand(buf *bytes.Buffer, args Arguments) error {
+	first := true
+	for _, v := range e.vars {
+		value, ok := args[v.name]
+		if !ok || value == nil {
+			continue
+		}
+		kind, s, list, keys, m := templateValue(value)
+		if kind == templateValueList && len(list) == 0 || kind == templateValueMap && len(keys) == 0 {
+			continue
+		}
+		if first {
+			buf.WriteString(e.op.first)
+			first = false
+		} else {
+			buf.WriteString(e.op.sep)
+		}
+		switch kind {
+		case templateValueString:
+			if v.prefix > 0 && utf8.RuneCountInString(s) > v.prefix {
+				s = string([]rune(s)[:v.prefix])
+			}
+			e.writeNamed(buf, v.name, s)
+		case templateValueList:
+			if v.prefix > 0 {
+				return fmt.Errorf("prefix modifier applied to list %q", v.name)
+			}
+			if v.explode {
+				for i, item := range list {
+					if i > 0 {
+						buf.WriteString(e.op.sep)
+					}
+					e.writeNamed(buf, v.name, item)
+				}
+				continue
+			}
+			if e.op.named {
+				buf.WriteString(encodeTemplateValue(v.name, true))
+				buf.WriteString("=")
+			}
+			for i, item := range list {
+				if i > 0 {
+					buf.WriteString(",")
+				}
+				buf.WriteString(encodeTemplateValue(item, e.op.allowReserved))
+			}
+		case templateValueMap:
+			if v.prefix > 0 {
+				return fmt.Errorf("prefix modifier applied to associative array %q", v.name)
+			}
+			if v.explode {
+				for i, k := range keys {
+					if i > 0 {
+						buf.WriteString(e.op.sep)
+					}
+					if e.op.named {
+						e.writeNamed(buf, k, m[k])
+						continue
+					}
+					buf.WriteString(encodeTemplateValue(k, e.op.allowReserved))
+					buf.WriteString("=")
+					buf.WriteString(encodeTemplateValue(m[k], e.op.allowReserved))
+				}
+				continue
+			}
+			if e.op.named {
+				buf.WriteString(encodeTemplateValue(v.name, true))
+				buf.WriteString("=")
+			}
+			for i, k := range keys {
+				if i > 0 {
+					buf.WriteString(",")
+				}
+				buf.WriteString(encodeTemplateValue(k, e.op.allowReserved))
+				buf.WriteString(",")
+				buf.WriteString(encodeTemplateValue(m[k], e.op.allowReserved))
+			}
+		}
+	}
+	return nil
+}
+
+func (e *templateExpression) writeNamed(buf *bytes.Buffer, name string, value string) {
+	if e.op.named {
+		buf.WriteString(encodeTemplateValue(name, true))
+		if value == "" {
+			buf.WriteString(e.op.ifEmpty)
+			return
+		}
+		buf.WriteString("=")
+	}
+	buf.WriteString(encodeTemplateValue(value, e.op.allowReserved))
+}
+
+func (e *templateExpression) match(s string, args Arguments) bool {
+	if s == "" {
+		return true
+	}
+	s = strings.TrimPrefix(s, e.op.first)
+	pieces := strings.Split(s, e.op.sep)
+	if e.op.named {
+		values := map[string][]string{}
+		var order []string
+		for _, piece := range pieces {
+			name, value := piece, ""
+			if i := strings.Index(piece, "="); i >= 0 {
+				name, value = piece[:i], piece[i+1:]
+			}
+			name = decodeTemplateValue(name)
+			if _, ok := values[name]; !ok {
+				order = append(order, name)
+			}
+			values[name] = append(values[name], value)
+		}
+		for _, v := range e.vars {
+			vs, ok := values[v.name]
+			if !ok {
+				continue
+			}
+			delete(values, v.name)
+			switch {
+			case len(vs) > 1:
+				args[v.name] = decodeTemplateValues(vs)
+			case v.explode:
+				args[v.name] = decodeTemplateValues(vs)
+			case strings.Contains(vs[0], ","):
+				args[v.name] = decodeTemplateValues(strings.Split(vs[0], ","))
+			default:
+				args[v.name] = decodeTemplateValue(vs[0])
+			}
+		}
+		if last := e.vars[len(e.vars)-1]; last.explode && len(values) > 0 {
+			m := map[string]interface{}{}
+			for _, name := range order {
+				if vs, ok := values[name]; ok {
+					m[name] = decodeTemplateValue(vs[0])
+				}
+			}
+			args[last.name] = m
+		}
+		return true
+	}
+	if len(e.vars) == 1 && !e.vars[0].explode {
+		if strings.Contains(s, ",") && !e.op.allowReserved {
+			args[e.vars[0].name] = decodeTemplateValues(strings.Split(s, ","))
+			return true
+		}
+		args[e.vars[0].name] = decodeTemplateValue(s)
+		return true
+	}
+	for i, v := range e.vars {
+		if i >= len(pieces) {
+			break
+		}
+		if v.explode {
+			rest := pieces[i:]
+			if strings.Contains(rest[0], "=") {
+				m := map[string]interface{}{}
+				for _, piece := range rest {
+					kv := strings.SplitN(piece, "=", 2)
+					if len(kv) != 2 {
+						return false
+					}
+					m[decodeTemplateValue(kv[0])] = decodeTemplateValue(kv[1])
+				}
+				args[v.name] = m
+			} else {
+				args[v.name] = decodeTemplateValues(rest)
+			}
+			break
+		}
+		args[v.name] = decodeTemplateValue(pieces[i])
+	}
+	return len(pieces) <= len(e.vars) || e.vars[len(e.vars)-1].explode
+}
+
+type templateValueKind int
+
+const (
+	templateValueString templateValueKind = iota
+	templateValueList
+	templateValueMap
+)
+
+func templateValue(v interface{}) (templateValueKind, string, []string, []string, map[string]string) {
+	switch v := v.(type) {
+	case []string:
+		return templateValueList, "", v, nil, nil
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, e := range v {
+			list = append(list, formValue(e))
+		}
+		return templateValueList, "", list, nil, nil
+	case map[string]string:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return templateValueMap, "", nil, keys, v
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		m := make(map[string]string, len(v))
+		for k, e := range v {
+			keys = append(keys, k)
+			m[k] = formValue(e)
+		}
+		sort.Strings(keys)
+		return templateValueMap, "", nil, keys, m
+	default:
+		return templateValueString, formValue(v), nil, nil, nil
+	}
+}
+
+const templateReserved = ":/?#[]@!$&'()*+,;="
+
+func isTemplateUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// encodeTemplateValue percent-encodes all characters that are not unreserved. If allowReserved
+// is set, reserved characters and existing percent-encoded triplets are kept as they are.
+func encodeTemplateValue(s string, allowReserved bool) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case isTemplateUnreserved(c):
+			buf.WriteByte(c)
+		case allowReserved && strings.IndexByte(templateReserved, c) >= 0:
+			buf.WriteByte(c)
+		case allowReserved && c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			buf.WriteString(s[i : i+3])
+			i += 2
+		default:
+			fmt.Fprintf(&buf, "%%%02X", c)
+		}
+	}
+	return buf.String()
+}
+
+// encodeTemplateLiteral percent-encodes the characters of a literal that are not allowed in a URI.
+func encodeTemplateLiteral(s string) string {
+	return encodeTemplateValue(s, true)
+}
+
+func decodeTemplateValue(s string) string {
+	v, err := url.PathUnescape(s)
+	if err != nil {
+		return s
+	}
+	return v
+}
+
+func decodeTemplateValues(ss []string) []string {
+	vs := make([]string, 0, len(ss))
+	for _, s := range ss {
+		vs = append(vs, decodeTemplateValue(s))
+	}
+	return vs
+}
@@ -0,0 +1,194 @@
+package hyper_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cognicraft/hyper"
+)
+
+func TestURITemplateExpand(t *testing.T) {
+	// examples from RFC 6570
+	args := hyper.Arguments{
+		"count": []string{"one", "two", "three"},
+		"dom":   []string{"example", "com"},
+		"dub":   "me/too",
+		"hello": "Hello World!",
+		"half":  "50%",
+		"var":   "value",
+		"who":   "fred",
+		"base":  "http://example.com/home/",
+		"path":  "/foo/bar",
+		"list":  []string{"red", "green", "blue"},
+		"keys":  map[string]string{"semi": ";", "dot": ".", "comma": ","},
+		"v":     "6",
+		"x":     "1024",
+		"y":     "768",
+		"empty": "",
+		"undef": nil,
+	}
+
+	tests := []struct {
+		template string
+		result   string
+	}{
+		{"{var}", "value"},
+		{"{hello}", "Hello%20World%21"},
+		{"{half}", "50%25"},
+		{"O{empty}X", "OX"},
+		{"O{undef}X", "OX"},
+		{"{x,y}", "1024,768"},
+		{"{x,hello,y}", "1024,Hello%20World%21,768"},
+		{"?{x,empty}", "?1024,"},
+		{"?{x,undef}", "?1024"},
+		{"{var:3}", "val"},
+		{"{var:30}", "value"},
+		{"{list}", "red,green,blue"},
+		{"{list*}", "red,green,blue"},
+		{"{keys}", "comma,%2C,dot,.,semi,%3B"},
+		{"{keys*}", "comma=%2C,dot=.,semi=%3B"},
+		{"{+var}", "value"},
+		{"{+hello}", "Hello%20World!"},
+		{"{+half}", "50%25"},
+		{"{base}index", "http%3A%2F%2Fexample.com%2Fhome%2Findex"},
+		{"{+base}index", "http://example.com/home/index"},
+		{"{+path}/here", "/foo/bar/here"},
+		{"here?ref={+path}", "here?ref=/foo/bar"},
+		{"{+path:6}/here", "/foo/b/here"},
+		{"{#var}", "#value"},
+		{"{#hello}", "#Hello%20World!"},
+		{"{#path:6}/here", "#/foo/b/here"},
+		{"{#keys*}", "#comma=,,dot=.,semi=;"},
+		{"X{.var}", "X.value"},
+		{"X{.x,y}", "X.1024.768"},
+		{"X{.list*}", "X.red.green.blue"},
+		{"{/var}", "/value"},
+		{"{/var,x}/here", "/value/1024/here"},
+		{"{/list*,path:4}", "/red/green/blue/%2Ffoo"},
+		{"{;x,y}", ";x=1024;y=768"},
+		{"{;x,y,empty}", ";x=1024;y=768;empty"},
+		{"{;list}", ";list=red,green,blue"},
+		{"{;list*}", ";list=red;list=green;list=blue"},
+		{"{;keys*}", ";comma=%2C;dot=.;semi=%3B"},
+		{"{?x,y}", "?x=1024&y=768"},
+		{"{?x,y,empty}", "?x=1024&y=768&empty="},
+		{"{?list*}", "?list=red&list=green&list=blue"},
+		{"{?keys*}", "?comma=%2C&dot=.&semi=%3B"},
+		{"?fixed=yes{&x}", "?fixed=yes&x=1024"},
+		{"{&var:3}", "&var=val"},
+		{"{count}", "one,two,three"},
+		{"{/count*}", "/one/two/three"},
+		{"{?dom*}", "?dom=example&dom=com"},
+		{"{.dom*}", ".example.com"},
+		{"{+dub}", "me/too"},
+		{"{who}", "fred"},
+	}
+	for _, test := range tests {
+		t.Run(test.template, func(t *testing.T) {
+			got, err := hyper.ExpandURITemplate(test.template, args)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.result != got {
+				t.Errorf("want: %s, got: %s", test.result, got)
+			}
+		})
+	}
+}
+
+func TestURITemplateParseError(t *testing.T) {
+	tests := []string{
+		"{",
+		"}",
+		"{}",
+		"{var:0}",
+		"{=var}",
+		"{va r}",
+	}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			if _, err := hyper.ParseURITemplate(test); err == nil {
+				t.Errorf("want: error, got: nil")
+			}
+		})
+	}
+}
+
+func TestURITemplateMatch(t *testing.T) {
+	tests := []struct {
+		template    string
+		uri         string
+		expectArgs  hyper.Arguments
+		expectMatch bool
+	}{
+		{
+			template:    "/orders/{id}",
+			uri:         "/orders/42",
+			expectArgs:  hyper.Arguments{"id": "42"},
+			expectMatch: true,
+		},
+		{
+			template:    "/orders/{id}",
+			uri:         "/customers/42",
+			expectMatch: false,
+		},
+		{
+			template:    "/orders/{id}/items/{item}",
+			uri:         "/orders/42/items/hello%20world",
+			expectArgs:  hyper.Arguments{"id": "42", "item": "hello world"},
+			expectMatch: true,
+		},
+		{
+			template:    "/search{?q,page}",
+			uri:         "/search?q=foo%20bar&page=2",
+			expectArgs:  hyper.Arguments{"q": "foo bar", "page": "2"},
+			expectMatch: true,
+		},
+		{
+			template:    "/search{?q,page}",
+			uri:         "/search",
+			expectArgs:  hyper.Arguments{},
+			expectMatch: true,
+		},
+		{
+			template:    "/files{/path*}{.ext}",
+			uri:         "/files/a/b/c.txt",
+			expectArgs:  hyper.Arguments{"path": []string{"a", "b", "c"}, "ext": "txt"},
+			expectMatch: true,
+		},
+		{
+			template:    "/tags{?tag*}",
+			uri:         "/tags?tag=a&tag=b",
+			expectArgs:  hyper.Arguments{"tag": []string{"a", "b"}},
+			expectMatch: true,
+		},
+		{
+			template:    "{+base}/index{#section}",
+			uri:         "http://example.com/home/index#intro",
+			expectArgs:  hyper.Arguments{"base": "http://example.com/home", "section": "intro"},
+			expectMatch: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.uri, func(t *testing.T) {
+			ut := hyper.MustParseURITemplate(test.template)
+			args, ok := ut.Match(test.uri)
+			if test.expectMatch != ok {
+				t.Fatalf("want: %v, got: %v", test.expectMatch, ok)
+			}
+			if !reflect.DeepEqual(test.expectArgs, args) {
+				t.Errorf("want: %#v, got: %#v", test.expectArgs, args)
+			}
+			if !ok {
+				return
+			}
+			uri, err := ut.Expand(args)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.uri != uri {
+				t.Errorf("want: %s, got: %s", test.uri, uri)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package hyper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// Codes of the Errors that are attached to Items that could not be transcluded.
+const (
+	CodeTransclusionFailed = "transclusion-failed"
+	CodeTransclusionCycle  = "transclusion-cycle"
+)
+
+// DefaultTransclusionDepth is the MaxDepth of a Transcluder without one.
+const DefaultTransclusionDepth = 3
+
+// DefaultTransclusionConcurrency is the Concurrency of a Transcluder without one.
+const DefaultTransclusionConcurrency = 4
+
+// Transcluder embeds the targets of Links with RenderTransclude into the Item that contains
+// them. The fetched Items are added to the sub-items with the rel of the Link, replacing a
+// rel of their own.
+//
+// Transclusion is recursive: Links in fetched Items are resolved against the URL they were
+// fetched from and transcluded as well, up to MaxDepth levels. A Link that points to a URL on
+// the chain of Items that contains it is not fetched. Failed fetches and cycles result in a
+// sub-item with the rel of the Link and an Error, so that the rest of the result is usable.
+type Transcluder struct {
+	Client *Client
+	// MaxDepth limits the nesting of transcluded Items, DefaultTransclusionDepth if zero.
+	MaxDepth int
+	// Concurrency limits the number of concurrent fetches, DefaultTransclusionConcurrency
+	// if zero.
+	Concurrency int
+}
+
+// NewTranscluder creates a Transcluder that fetches with the Client.
+func NewTranscluder(c *Client) *Transcluder {
+	return &Transcluder{
+		Client: c,
+	}
+}
+
+// Transclude returns a copy of root, which was fetched from base, with all Links with
+// RenderTransclude embedded. The root itself is not modified.
+func (t *Transcluder) Transclude(ctx context.Context, base string, root Item) Item {
+	concurrency := t.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultTransclusionConcurrency
+	}
+	tr := &transclusion{
+		Transcluder: t,
+		sem:         make(chan struct{}, concurrency),
+	}
+	if u, err := resolveHref("", base); err == nil {
+		base = u
+	}
+	return tr.resolve(ctx, Transform(root, Transformer{}), base, []string{base}, 0)
+}
+
+type transclusion struct {
+	*Transcluder
+	sem chan struct{}
+}
+
+func (tr *transclusion) maxDepth() int {
+	if tr.MaxDepth <= 0 {
+		return DefaultTransclusionDepth
+	}
+	return tr.MaxDepth
+}
+
+// resolve transcludes the Links of i and its sub-items. chain holds the URLs of the
+// transcluded Items that contain i.
+func (tr *transclusion) resolve(ctx context.Context, i Item, base string, chain []string, depth int) Item {
+	var links Links
+	if depth < tr.maxDepth() {
+		links = i.Links.Filter(func(l Link) bool {
+			return l.Render == RenderTransclude
+		})
+	}
+	subs := make(Items, len(i.Items)+len(links))
+	var wg sync.WaitGroup
+	for k, sub := range i.Items {
+		wg.Add(1)
+		go func(k int, sub Item) {
+			defer wg.Done()
+			subs[k] = tr.resolve(ctx, sub, base, chain, depth)
+		}(k, sub)
+	}
+	for k, l := range links {
+		wg.Add(1)
+		go func(k int, l Link) {
+			defer wg.Done()
+			subs[k] = tr.transclude(ctx, l, base, chain, depth)
+		}(len(i.Items)+k, l)
+	}
+	wg.Wait()
+	if len(subs) > 0 {
+		i.Items = subs
+	}
+	return i
+}
+
+// transclude fetches the target of l and resolves its Links.
+func (tr *transclusion) transclude(ctx context.Context, l Link, base string, chain []string, depth int) Item {
+	fail := func(code string, err error) Item {
+		return Item{
+			Rel:    l.Rel,
+			Errors: Errors{{Message: err.Error(), Code: code}},
+		}
+	}
+	href, err := resolveHref(base, l.Href)
+	if err != nil {
+		return fail(CodeTransclusionFailed, err)
+	}
+	for _, u := range chain {
+		if u == href {
+			return fail(CodeTransclusionCycle, fmt.Errorf("transclude %s: cycle", href))
+		}
+	}
+	i, err := tr.fetch(ctx, l, href)
+	if err != nil {
+		return fail(CodeTransclusionFailed, fmt.Errorf("transclude %s: %v", href, err))
+	}
+	i.Rel = l.Rel
+	next := make([]string, len(chain), len(chain)+1)
+	copy(next, chain)
+	return tr.resolve(ctx, i, href, append(next, href), depth+1)
+}
+
+func (tr *transclusion) fetch(ctx context.Context, l Link, href string) (Item, error) {
+	select {
+	case tr.sem <- struct{}{}:
+	case <-ctx.Done():
+		return Item{}, ctx.Err()
+	}
+	defer func() { <-tr.sem }()
+	req, err := http.NewRequestWithContext(ctx, MethodGET, href, nil)
+	if err != nil {
+		return Item{}, err
+	}
+	if l.Accept != "" {
+		req.Header.Set(HeaderAccept, l.Accept)
+	}
+	if l.AcceptLanguage != "" {
+		req.Header.Set(HeaderAcceptLanguage, l.AcceptLanguage)
+	}
+	res, err := tr.Client.Do(req)
+	if err != nil {
+		return Item{}, err
+	}
+	return res.Item, nil
+}
+
+// resolveHref resolves href against base. An empty base leaves href unchanged.
+func resolveHref(base string, href string) (string, error) {
+	ref, err := url.Parse(href)
+	if err != nil {
+		return "", err
+	}
+	if base == "" {
+		return ref.String(), nil
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	return b.ResolveReference(ref).String(), nil
+}
@@ -0,0 +1,123 @@
+package hyper_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cognicraft/hyper"
+)
+
+func TestTranscluder(t *testing.T) {
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+	pages := map[string]hyper.Item{
+		"/orders/1": {
+			ID: "order:1",
+			Links: hyper.Links{
+				{Rel: "customer", Href: "../customers/7", Render: hyper.RenderTransclude},
+				{Rel: "self", Href: "/orders/1"},
+			},
+		},
+		"/customers/7": {
+			ID: "customer:7",
+			Links: hyper.Links{
+				{Rel: "orders", Href: "/orders/1", Render: hyper.RenderTransclude},
+				{Rel: "address", Href: "/customers/7/address", Render: hyper.RenderTransclude},
+			},
+		},
+		"/customers/7/address": {
+			ID:  "address",
+			Rel: "home",
+		},
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			active--
+			mu.Unlock()
+		}()
+		time.Sleep(5 * time.Millisecond)
+		i, ok := pages[r.URL.Path]
+		if !ok {
+			hyper.WriteError(w, http.StatusNotFound, fmt.Errorf("not found: %s", r.URL.Path))
+			return
+		}
+		hyper.Write(w, http.StatusOK, i)
+	}))
+	defer s.Close()
+
+	root := hyper.Item{
+		ID: "dashboard",
+		Links: hyper.Links{
+			{Rel: "latest", Href: "orders/1", Render: hyper.RenderTransclude},
+			{Rel: "broken", Href: "/missing", Render: hyper.RenderTransclude},
+			{Rel: "help", Href: "/help"},
+		},
+		Items: hyper.Items{
+			{ID: "news", Links: hyper.Links{{Rel: "feed", Href: "/customers/7/address", Render: hyper.RenderTransclude}}},
+		},
+	}
+
+	tr := hyper.NewTranscluder(hyper.NewClient())
+	tr.Concurrency = 1
+	res := tr.Transclude(context.Background(), s.URL+"/", root)
+
+	if len(root.Items) != 1 || len(root.Items[0].Items) != 0 {
+		t.Fatalf("root was modified: %s", hyper.JSONString(root))
+	}
+	if maxActive != 1 {
+		t.Errorf("want: 1 concurrent fetch, got: %d", maxActive)
+	}
+
+	tests := []struct {
+		q      string
+		result interface{}
+	}{
+		{q: ".items[*].id", result: []interface{}{"news", "order:1", ""}},
+		{q: ".items[*].rel", result: []interface{}{"", "latest", "broken"}},
+		{q: `#"news".items[0].id`, result: "address"},
+		{q: `#"news".items[0].rel`, result: "feed"},
+		{q: `#"order:1".items[0].id`, result: "customer:7"},
+		{q: `#"order:1".items[0].rel`, result: "customer"},
+		{q: `#"customer:7".items[*].rel`, result: []interface{}{"orders", "address"}},
+		{q: `#"customer:7".items[rel=orders].errors[0].code`, result: hyper.CodeTransclusionCycle},
+		{q: ".items[rel=broken].errors[0].code", result: hyper.CodeTransclusionFailed},
+	}
+	for _, test := range tests {
+		t.Run(test.q, func(t *testing.T) {
+			got, err := hyper.EvalQuery(res, test.q)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !equalJSON(test.result, got) {
+				t.Errorf("want: %s, got: %s", hyper.JSONString(test.result), hyper.JSONString(got))
+			}
+		})
+	}
+	msg := hyper.Query(res, ".items[rel=broken].errors[0].message")
+	if s, _ := msg.(string); !strings.Contains(s, "404") {
+		t.Errorf("want: message with status, got: %v", msg)
+	}
+
+	tr = &hyper.Transcluder{Client: hyper.NewClient(), MaxDepth: 1}
+	res = tr.Transclude(context.Background(), s.URL+"/", root)
+	if got := hyper.Query(res, `#"order:1".items`); !equalJSON(nil, got) {
+		t.Errorf("want: no items beyond max depth, got: %s", hyper.JSONString(got))
+	}
+}
+
+func equalJSON(a, b interface{}) bool {
+	return hyper.JSONString(a) == hyper.JSONString(b)
+}
@@ -0,0 +1,215 @@
+package hyper
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Error codes used by Validate.
+const (
+	CodeRequired       = "required"
+	CodeReadOnly       = "read-only"
+	CodeUnknown        = "unknown"
+	CodeMultiple       = "multiple"
+	CodeType           = "type"
+	CodePattern        = "pattern"
+	CodeMaxLength      = "max-length"
+	CodeMin            = "min"
+	CodeMax            = "max"
+	CodeStep           = "step"
+	CodeOption         = "option"
+	CodeInvalidPattern = "invalid-pattern"
+)
+
+// Validate checks the Arguments of the Command against the Parameters that are declared by
+// the Action. It enforces Required, ReadOnly, Multiple, Pattern, MaxLength, Min, Max and Step,
+// checks that values of parameters with Options are among them (including nested groups) and
+// rejects arguments for which no parameter is declared. Every violation results in an Error
+// whose Label is the name of the offending parameter and whose Code tells the violated
+// constraint. A Pattern that does not compile is reported with CodeInvalidPattern, whether
+// or not a value is given, so that a mistake in the definition of the Action does not go
+// unnoticed. A valid Command results in nil.
+//
+// Arguments that are absent or empty are set to the Value of their Parameter, if it has one,
+// so that handlers receive the default. Such a default satisfies Required.
+func Validate(a Action, c Command) Errors {
+	var errs Errors
+	fail := func(p Parameter, code string, format string, args ...interface{}) {
+		errs = append(errs, Error{
+			Label:   p.Name,
+			Message: fmt.Sprintf(format, args...),
+			Code:    code,
+		})
+	}
+	declared := map[string]bool{NameAction: true}
+	for _, p := range a.Parameters {
+		declared[p.Name] = true
+		if p.Name == NameAction {
+			continue
+		}
+		var re *regexp.Regexp
+		if p.Pattern != "" {
+			var err error
+			if re, err = regexp.Compile("^(?:" + p.Pattern + ")$"); err != nil {
+				fail(p, CodeInvalidPattern, "%s has an invalid pattern %s: %v", p.Name, p.Pattern, err)
+				continue
+			}
+		}
+		value, ok := c.Arguments[p.Name]
+		if ok && p.ReadOnly && formValue(value) != formValue(p.Value) {
+			fail(p, CodeReadOnly, "%s is read-only", p.Name)
+			continue
+		}
+		values := argumentValues(value)
+		if !ok || len(values) == 0 {
+			switch {
+			case p.Value != nil && c.Arguments != nil:
+				c.Arguments[p.Name] = p.Value
+			case p.Required:
+				fail(p, CodeRequired, "%s is required", p.Name)
+			}
+			continue
+		}
+		if len(values) > 1 && !p.Multiple {
+			fail(p, CodeMultiple, "%s does not accept multiple values", p.Name)
+			continue
+		}
+		for _, v := range values {
+			if msg, code := validateValue(p, re, v); code != "" {
+				fail(p, code, "%s %s", p.Name, msg)
+				break
+			}
+		}
+	}
+	var unknown []string
+	for name := range c.Arguments {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		fail(Parameter{Name: name}, CodeUnknown, "%s is unknown", name)
+	}
+	return errs
+}
+
+// argumentValues returns the individual values of an argument. Empty strings count as absent.
+func argumentValues(v interface{}) []interface{} {
+	var values []interface{}
+	switch v := v.(type) {
+	case nil:
+	case []interface{}:
+		values = v
+	case []string:
+		for _, s := range v {
+			values = append(values, s)
+		}
+	default:
+		values = []interface{}{v}
+	}
+	res := values[:0:0]
+	for _, v := range values {
+		if s, ok := v.(string); ok && s == "" {
+			continue
+		}
+		res = append(res, v)
+	}
+	return res
+}
+
+func validateValue(p Parameter, re *regexp.Regexp, v interface{}) (string, string) {
+	s := formValue(v)
+	f, isNumber := toFloat(v)
+	if (p.Type == TypeNumber || p.Type == TypeRange) && !isNumber {
+		return fmt.Sprintf("must be a number, got %q", s), CodeType
+	}
+	if re != nil && !re.MatchString(s) {
+		return fmt.Sprintf("must match the pattern %s", p.Pattern), CodePattern
+	}
+	if max, ok := toFloat(p.MaxLength); ok && float64(utf8.RuneCountInString(s)) > max {
+		return fmt.Sprintf("must not be longer than %v characters", p.MaxLength), CodeMaxLength
+	}
+	if p.Min != nil && compareValues(v, p.Min) < 0 {
+		return fmt.Sprintf("must not be less than %v", p.Min), CodeMin
+	}
+	if p.Max != nil && compareValues(v, p.Max) > 0 {
+		return fmt.Sprintf("must not be greater than %v", p.Max), CodeMax
+	}
+	if step, ok := toFloat(p.Step); ok && step > 0 && isNumber {
+		base, _ := toFloat(p.Min)
+		n := (f - base) / step
+		if math.Abs(n-math.Round(n)) > 1e-9 {
+			return fmt.Sprintf("must be a multiple of %v", p.Step), CodeStep
+		}
+	}
+	if len(p.Options) > 0 && !containsOption(p.Options, s) {
+		return fmt.Sprintf("must be one of the options, got %q", s), CodeOption
+	}
+	return "", ""
+}
+
+// compareValues compares numerically if both values are numbers and lexically otherwise,
+// which works for ISO 8601 dates and times.
+func compareValues(a, b interface{}) int {
+	fa, okA := toFloat(a)
+	fb, okB := toFloat(b)
+	if okA && okB {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		default:
+			return 0
+		}
+	}
+	sa, sb := formValue(a), formValue(b)
+	switch {
+	case sa < sb:
+		return -1
+	case sa > sb:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func containsOption(os SelectOptions, value string) bool {
+	for _, o := range os {
+		if len(o.Options) > 0 {
+			if containsOption(o.Options, value) {
+				return true
+			}
+			continue
+		}
+		if formValue(o.Value) == value {
+			return true
+		}
+	}
+	return false
+}
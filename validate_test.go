@@ -0,0 +1,167 @@
+package hyper_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cognicraft/hyper"
+)
+
+func TestValidate(t *testing.T) {
+	action := hyper.Action{
+		Rel: "order",
+		Parameters: hyper.Parameters{
+			hyper.ActionParameter("order"),
+			{Name: "id", Type: hyper.TypeHidden, Value: "42", ReadOnly: true},
+			{Name: "name", Type: hyper.TypeText, Required: true, Pattern: "[a-z]+", MaxLength: 5},
+			{Name: "quantity", Type: hyper.TypeNumber, Min: 1, Max: 10, Step: 2},
+			{Name: "due", Type: "date", Min: "2020-01-01"},
+			{
+				Name:     "color",
+				Type:     "select",
+				Multiple: true,
+				Options: hyper.SelectOptions{
+					{Value: "red"},
+					{Label: "Others", Options: hyper.SelectOptions{{Value: "green"}, {Value: "blue"}}},
+				},
+			},
+			{Name: "size", Type: "select", Options: hyper.SelectOptions{{Value: "S"}, {Value: "M"}}},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		args   hyper.Arguments
+		expect []string
+	}{
+		{
+			name:   "valid",
+			args:   hyper.Arguments{"id": "42", "name": "foo", "quantity": 3.0, "due": "2020-02-01", "color": []string{"red", "blue"}},
+			expect: nil,
+		},
+		{
+			name:   "required",
+			args:   hyper.Arguments{"name": ""},
+			expect: []string{"name:required"},
+		},
+		{
+			name:   "read-only",
+			args:   hyper.Arguments{"id": "43", "name": "foo"},
+			expect: []string{"id:read-only"},
+		},
+		{
+			name:   "pattern",
+			args:   hyper.Arguments{"name": "Foo"},
+			expect: []string{"name:pattern"},
+		},
+		{
+			name:   "max-length",
+			args:   hyper.Arguments{"name": "foobar"},
+			expect: []string{"name:max-length"},
+		},
+		{
+			name:   "range",
+			args:   hyper.Arguments{"name": "foo", "quantity": "12", "due": "2019-12-31"},
+			expect: []string{"quantity:max", "due:min"},
+		},
+		{
+			name:   "step",
+			args:   hyper.Arguments{"name": "foo", "quantity": 2.0},
+			expect: []string{"quantity:step"},
+		},
+		{
+			name:   "type",
+			args:   hyper.Arguments{"name": "foo", "quantity": "many"},
+			expect: []string{"quantity:type"},
+		},
+		{
+			name:   "options",
+			args:   hyper.Arguments{"name": "foo", "color": "yellow", "size": []string{"S", "M"}},
+			expect: []string{"color:option", "size:multiple"},
+		},
+		{
+			name:   "unknown",
+			args:   hyper.Arguments{"name": "foo", "zzz": 1.0, "aaa": 2.0},
+			expect: []string{"aaa:unknown", "zzz:unknown"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errs := hyper.Validate(action, hyper.Command{Action: "order", Arguments: test.args})
+			var got []string
+			for _, e := range errs {
+				got = append(got, e.Label+":"+e.Code)
+			}
+			if !reflect.DeepEqual(test.expect, got) {
+				t.Errorf("want: %v, got: %v (%s)", test.expect, got, hyper.JSONString(errs))
+			}
+		})
+	}
+}
+
+func TestValidateInvalidPattern(t *testing.T) {
+	action := hyper.Action{
+		Parameters: hyper.Parameters{
+			{Name: "name", Type: hyper.TypeText, Pattern: "[a-z+"},
+		},
+	}
+	for _, args := range []hyper.Arguments{{"name": "foo"}, {}} {
+		errs := hyper.Validate(action, hyper.Command{Arguments: args})
+		if len(errs) != 1 || errs[0].Code != hyper.CodeInvalidPattern {
+			t.Errorf("want: %s, got: %s", hyper.CodeInvalidPattern, hyper.JSONString(errs))
+		}
+	}
+}
+
+func TestValidateDefaults(t *testing.T) {
+	action := hyper.Action{
+		Parameters: hyper.Parameters{
+			{Name: "currency", Type: hyper.TypeText, Required: true, Value: "EUR"},
+			{Name: "note", Type: hyper.TypeText, Required: true},
+			{Name: "page", Type: hyper.TypeNumber, Value: 1.0},
+		},
+	}
+	tests := []struct {
+		name       string
+		args       hyper.Arguments
+		expect     []string
+		expectArgs hyper.Arguments
+	}{
+		{
+			name:       "absent",
+			args:       hyper.Arguments{"note": "x"},
+			expectArgs: hyper.Arguments{"currency": "EUR", "note": "x", "page": 1.0},
+		},
+		{
+			name:       "empty",
+			args:       hyper.Arguments{"currency": "", "note": "x", "page": ""},
+			expectArgs: hyper.Arguments{"currency": "EUR", "note": "x", "page": 1.0},
+		},
+		{
+			name:       "given",
+			args:       hyper.Arguments{"currency": "USD", "note": "x", "page": 2.0},
+			expectArgs: hyper.Arguments{"currency": "USD", "note": "x", "page": 2.0},
+		},
+		{
+			name:       "required-without-default",
+			args:       hyper.Arguments{"note": ""},
+			expect:     []string{"note:required"},
+			expectArgs: hyper.Arguments{"currency": "EUR", "note": "", "page": 1.0},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errs := hyper.Validate(action, hyper.Command{Arguments: test.args})
+			var got []string
+			for _, e := range errs {
+				got = append(got, e.Label+":"+e.Code)
+			}
+			if !reflect.DeepEqual(test.expect, got) {
+				t.Errorf("want: %v, got: %v", test.expect, got)
+			}
+			if !reflect.DeepEqual(test.expectArgs, test.args) {
+				t.Errorf("want: %v, got: %v", test.expectArgs, test.args)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package hyper
+
+import "errors"
+
+// SkipChildren can be returned by the Enter callbacks of a Visitor to skip the contents of an
+// Item, Link or Action. The corresponding Leave callback is still called.
+var SkipChildren = errors.New("skip children")
+
+// Visitor holds the callbacks of Walk. Nil callbacks are skipped. Every callback receives the
+// ItemPath of the Item that is visited or that contains the visited part.
+type Visitor struct {
+	EnterItem   func(p ItemPath, i Item) error
+	LeaveItem   func(p ItemPath, i Item) error
+	Property    func(p ItemPath, prop Property) error
+	EnterLink   func(p ItemPath, l Link) error
+	LeaveLink   func(p ItemPath, l Link) error
+	EnterAction func(p ItemPath, a Action) error
+	LeaveAction func(p ItemPath, a Action) error
+	Parameter   func(p ItemPath, prm Parameter) error
+}
+
+// Walk visits the Item tree depth-first. The contents of an Item are visited in the order
+// Properties, Links with their Parameters, Actions with their Parameters and sub-items.
+// Walk stops at the first error other than SkipChildren and returns it.
+func Walk(root Item, v Visitor) error {
+	return walkItem(nil, root, v)
+}
+
+func walkItem(p ItemPath, i Item, v Visitor) error {
+	var err error
+	if v.EnterItem != nil {
+		err = v.EnterItem(p, i)
+	}
+	if err == nil {
+		err = walkItemContents(p, i, v)
+	}
+	if err != nil && err != SkipChildren {
+		return err
+	}
+	if v.LeaveItem != nil {
+		return v.LeaveItem(p, i)
+	}
+	return nil
+}
+
+func walkItemContents(p ItemPath, i Item, v Visitor) error {
+	if v.Property != nil {
+		for _, prop := range i.Properties {
+			if err := v.Property(p, prop); err != nil {
+				return err
+			}
+		}
+	}
+	for _, l := range i.Links {
+		if err := walkLink(p, l, v); err != nil {
+			return err
+		}
+	}
+	for _, a := range i.Actions {
+		if err := walkAction(p, a, v); err != nil {
+			return err
+		}
+	}
+	for k, sub := range i.Items {
+		sp := make(ItemPath, len(p), len(p)+1)
+		copy(sp, p)
+		if err := walkItem(append(sp, PathSegment{Index: k, ID: sub.ID}), sub, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkLink(p ItemPath, l Link, v Visitor) error {
+	var err error
+	if v.EnterLink != nil {
+		err = v.EnterLink(p, l)
+	}
+	if err == nil {
+		err = walkParameters(p, l.Parameters, v)
+	}
+	if err != nil && err != SkipChildren {
+		return err
+	}
+	if v.LeaveLink != nil {
+		return v.LeaveLink(p, l)
+	}
+	return nil
+}
+
+func walkAction(p ItemPath, a Action, v Visitor) error {
+	var err error
+	if v.EnterAction != nil {
+		err = v.EnterAction(p, a)
+	}
+	if err == nil {
+		err = walkParameters(p, a.Parameters, v)
+	}
+	if err != nil && err != SkipChildren {
+		return err
+	}
+	if v.LeaveAction != nil {
+		return v.LeaveAction(p, a)
+	}
+	return nil
+}
+
+func walkParameters(p ItemPath, prms Parameters, v Visitor) error {
+	if v.Parameter == nil {
+		return nil
+	}
+	for _, prm := range prms {
+		if err := v.Parameter(p, prm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Transformer holds the functions of Transform. Each function returns the replacement of its
+// argument and false to remove it. Nil functions keep the parts unchanged.
+type Transformer struct {
+	// Item is called before the contents of the Item are transformed.
+	Item      func(i Item) (Item, bool)
+	Property  func(p Property) (Property, bool)
+	Link      func(l Link) (Link, bool)
+	Action    func(a Action) (Action, bool)
+	Parameter func(p Parameter) (Parameter, bool)
+}
+
+// Transform returns a transformed copy of the Item tree. The Properties, Links, Actions,
+// Parameters, Items and Errors of the copy do not share memory with the original, so
+// neither the Transformer nor later changes of the result can modify root. Values like
+// Property.Value or Item.Data are not copied. If the root is removed, the zero Item is returned.
+func Transform(root Item, t Transformer) Item {
+	res, _ := transformItem(copyItem(root), t)
+	return res
+}
+
+// transformItem transforms i, which must not share memory with root (see copyItem), since
+// the Transformer may modify it.
+func transformItem(i Item, t Transformer) (Item, bool) {
+	if t.Item != nil {
+		var keep bool
+		if i, keep = t.Item(i); !keep {
+			return Item{}, false
+		}
+	}
+	if i.Properties != nil {
+		ps := make(Properties, 0, len(i.Properties))
+		for _, p := range i.Properties {
+			if t.Property != nil {
+				var keep bool
+				if p, keep = t.Property(p); !keep {
+					continue
+				}
+			}
+			ps = append(ps, p)
+		}
+		i.Properties = ps
+	}
+	if i.Links != nil {
+		ls := make(Links, 0, len(i.Links))
+		for _, l := range i.Links {
+			l.Parameters = transformParameters(l.Parameters, t)
+			if t.Link != nil {
+				var keep bool
+				if l, keep = t.Link(l); !keep {
+					continue
+				}
+			}
+			ls = append(ls, l)
+		}
+		i.Links = ls
+	}
+	if i.Actions != nil {
+		as := make(Actions, 0, len(i.Actions))
+		for _, a := range i.Actions {
+			a.Parameters = transformParameters(a.Parameters, t)
+			if t.Action != nil {
+				var keep bool
+				if a, keep = t.Action(a); !keep {
+					continue
+				}
+			}
+			as = append(as, a)
+		}
+		i.Actions = as
+	}
+	if i.Items != nil {
+		subs := make(Items, 0, len(i.Items))
+		for _, sub := range i.Items {
+			if sub, keep := transformItem(sub, t); keep {
+				subs = append(subs, sub)
+			}
+		}
+		i.Items = subs
+	}
+	return i, true
+}
+
+func transformParameters(prms Parameters, t Transformer) Parameters {
+	if prms == nil {
+		return nil
+	}
+	res := make(Parameters, 0, len(prms))
+	for _, p := range prms {
+		if t.Parameter != nil {
+			var keep bool
+			if p, keep = t.Parameter(p); !keep {
+				continue
+			}
+		}
+		res = append(res, p)
+	}
+	return res
+}
+
+// copyItem returns a copy of the Item tree that does not share memory with i, except for
+// values like Property.Value or Item.Data.
+func copyItem(i Item) Item {
+	if i.Properties != nil {
+		i.Properties = append(Properties{}, i.Properties...)
+	}
+	if i.Links != nil {
+		ls := make(Links, len(i.Links))
+		for k, l := range i.Links {
+			l.Parameters = copyParameters(l.Parameters)
+			ls[k] = l
+		}
+		i.Links = ls
+	}
+	if i.Actions != nil {
+		as := make(Actions, len(i.Actions))
+		for k, a := range i.Actions {
+			a.Parameters = copyParameters(a.Parameters)
+			as[k] = a
+		}
+		i.Actions = as
+	}
+	if i.Items != nil {
+		subs := make(Items, len(i.Items))
+		for k, sub := range i.Items {
+			subs[k] = copyItem(sub)
+		}
+		i.Items = subs
+	}
+	if i.Errors != nil {
+		i.Errors = append(Errors{}, i.Errors...)
+	}
+	return i
+}
+
+func copyParameters(prms Parameters) Parameters {
+	if prms == nil {
+		return nil
+	}
+	res := make(Parameters, len(prms))
+	for k, p := range prms {
+		p.Options = copySelectOptions(p.Options)
+		res[k] = p
+	}
+	return res
+}
+
+func copySelectOptions(os SelectOptions) SelectOptions {
+	if os == nil {
+		return nil
+	}
+	res := make(SelectOptions, len(os))
+	for k, o := range os {
+		o.Options = copySelectOptions(o.Options)
+		res[k] = o
+	}
+	return res
+}
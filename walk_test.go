@@ -0,0 +1,230 @@
+package hyper_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cognicraft/hyper"
+)
+
+func walkTestItem() hyper.Item {
+	return hyper.Item{
+		ID:         "root",
+		Properties: hyper.Properties{{Name: "secret", Value: "s3cr3t"}, {Name: "name", Value: "root"}},
+		Links: hyper.Links{
+			{Rel: "self", Href: "http://backend/orders"},
+			{Rel: "search", Template: "http://backend/orders{?q}", Parameters: hyper.Parameters{{Name: "q", Type: hyper.TypeText}}},
+		},
+		Actions: hyper.Actions{
+			{Rel: "delete", Href: "http://backend/orders", Method: "DELETE"},
+			{Rel: "create", Href: "http://backend/orders", Parameters: hyper.Parameters{
+				{Name: "kind", Type: hyper.TypeText, Options: hyper.SelectOptions{{Value: "a"}, {Value: "b"}}},
+			}},
+		},
+		Items: hyper.Items{
+			{ID: "a", Type: "order", Links: hyper.Links{{Rel: "self", Href: "http://backend/orders/a"}}},
+			{ID: "b", Type: "draft"},
+		},
+		Errors: hyper.Errors{{Message: "oops"}},
+	}
+}
+
+func TestWalk(t *testing.T) {
+	var events []string
+	log := func(format string, args ...string) {
+		events = append(events, format+" "+strings.Join(args, " "))
+	}
+	v := hyper.Visitor{
+		EnterItem: func(p hyper.ItemPath, i hyper.Item) error {
+			log("enter-item", p.String(), i.ID)
+			if i.ID == "b" {
+				return hyper.SkipChildren
+			}
+			return nil
+		},
+		LeaveItem: func(p hyper.ItemPath, i hyper.Item) error {
+			log("leave-item", i.ID)
+			return nil
+		},
+		Property: func(p hyper.ItemPath, prop hyper.Property) error {
+			log("property", prop.Name)
+			return nil
+		},
+		EnterLink: func(p hyper.ItemPath, l hyper.Link) error {
+			log("enter-link", l.Rel)
+			return nil
+		},
+		LeaveLink: func(p hyper.ItemPath, l hyper.Link) error {
+			log("leave-link", l.Rel)
+			return nil
+		},
+		EnterAction: func(p hyper.ItemPath, a hyper.Action) error {
+			log("enter-action", a.Rel)
+			if a.Rel == "create" {
+				return hyper.SkipChildren
+			}
+			return nil
+		},
+		LeaveAction: func(p hyper.ItemPath, a hyper.Action) error {
+			log("leave-action", a.Rel)
+			return nil
+		},
+		Parameter: func(p hyper.ItemPath, prm hyper.Parameter) error {
+			log("parameter", prm.Name)
+			return nil
+		},
+	}
+	if err := hyper.Walk(walkTestItem(), v); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		"enter-item . root",
+		"property secret",
+		"property name",
+		"enter-link self",
+		"leave-link self",
+		"enter-link search",
+		"parameter q",
+		"leave-link search",
+		"enter-action delete",
+		"leave-action delete",
+		"enter-action create",
+		"leave-action create",
+		"enter-item .items[0] a",
+		"enter-link self",
+		"leave-link self",
+		"leave-item a",
+		"enter-item .items[1] b",
+		"leave-item b",
+		"leave-item root",
+	}
+	if !reflect.DeepEqual(want, events) {
+		t.Errorf("want: %q, got: %q", want, events)
+	}
+}
+
+func TestWalkError(t *testing.T) {
+	stop := errors.New("stop")
+	var visited []string
+	err := hyper.Walk(walkTestItem(), hyper.Visitor{
+		EnterItem: func(p hyper.ItemPath, i hyper.Item) error {
+			visited = append(visited, i.ID)
+			if i.ID == "a" {
+				return stop
+			}
+			return nil
+		},
+	})
+	if err != stop {
+		t.Errorf("want: %v, got: %v", stop, err)
+	}
+	if want := []string{"root", "a"}; !reflect.DeepEqual(want, visited) {
+		t.Errorf("want: %v, got: %v", want, visited)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	root := walkTestItem()
+	orig := walkTestItem()
+
+	rewrite := func(href string) string {
+		return strings.Replace(href, "http://backend", "https://gateway/api", 1)
+	}
+	res := hyper.Transform(root, hyper.Transformer{
+		Item: func(i hyper.Item) (hyper.Item, bool) {
+			return i, i.Type != "draft"
+		},
+		Property: func(p hyper.Property) (hyper.Property, bool) {
+			if p.Name == "secret" {
+				p.Value = "***"
+			}
+			return p, true
+		},
+		Link: func(l hyper.Link) (hyper.Link, bool) {
+			l.Href = rewrite(l.Href)
+			l.Template = rewrite(l.Template)
+			return l, true
+		},
+		Action: func(a hyper.Action) (hyper.Action, bool) {
+			a.Href = rewrite(a.Href)
+			return a, a.Method != "DELETE"
+		},
+		Parameter: func(p hyper.Parameter) (hyper.Parameter, bool) {
+			p.Label = strings.ToUpper(p.Name)
+			return p, true
+		},
+	})
+
+	if !reflect.DeepEqual(orig, root) {
+		t.Fatalf("root was modified: %s", hyper.JSONString(root))
+	}
+	want := hyper.Item{
+		ID:         "root",
+		Properties: hyper.Properties{{Name: "secret", Value: "***"}, {Name: "name", Value: "root"}},
+		Links: hyper.Links{
+			{Rel: "self", Href: "https://gateway/api/orders"},
+			{Rel: "search", Template: "https://gateway/api/orders{?q}", Parameters: hyper.Parameters{{Label: "Q", Name: "q", Type: hyper.TypeText}}},
+		},
+		Actions: hyper.Actions{
+			{Rel: "create", Href: "https://gateway/api/orders", Parameters: hyper.Parameters{
+				{Label: "KIND", Name: "kind", Type: hyper.TypeText, Options: hyper.SelectOptions{{Value: "a"}, {Value: "b"}}},
+			}},
+		},
+		Items: hyper.Items{
+			{ID: "a", Type: "order", Links: hyper.Links{{Rel: "self", Href: "https://gateway/api/orders/a"}}},
+		},
+		Errors: hyper.Errors{{Message: "oops"}},
+	}
+	if !reflect.DeepEqual(want, res) {
+		t.Errorf("want: %s, got: %s", hyper.JSONString(want), hyper.JSONString(res))
+	}
+
+	// the copy does not share memory with the original
+	res.Actions[0].Parameters[0].Options[0].Value = "x"
+	res.Errors[0].Message = "changed"
+	res.Items[0].Links[0].Href = "changed"
+	if !reflect.DeepEqual(orig, root) {
+		t.Errorf("root was modified through the copy: %s", hyper.JSONString(root))
+	}
+}
+
+func TestTransformItemMutation(t *testing.T) {
+	root := walkTestItem()
+	orig := walkTestItem()
+
+	hyper.Transform(root, hyper.Transformer{
+		Item: func(i hyper.Item) (hyper.Item, bool) {
+			// the Item passed to the Transformer must not share memory with root
+			for k := range i.Properties {
+				i.Properties[k].Value = "changed"
+			}
+			for k := range i.Links {
+				i.Links[k].Href = "changed"
+			}
+			for k := range i.Actions {
+				for n := range i.Actions[k].Parameters {
+					i.Actions[k].Parameters[n].Name = "changed"
+					for o := range i.Actions[k].Parameters[n].Options {
+						i.Actions[k].Parameters[n].Options[o].Value = "changed"
+					}
+				}
+			}
+			for k := range i.Items {
+				i.Items[k].ID = "changed"
+				if len(i.Items[k].Links) > 0 {
+					i.Items[k].Links[0].Href = "changed"
+				}
+			}
+			for k := range i.Errors {
+				i.Errors[k].Message = "changed"
+			}
+			return i, true
+		},
+	})
+
+	if !reflect.DeepEqual(orig, root) {
+		t.Errorf("root was modified by the Transformer: %s", hyper.JSONString(root))
+	}
+}